@@ -1,16 +1,22 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
+	_ "time/tzdata"
 
 	"runmate_api/config"
 	"runmate_api/http/handler"
+	"runmate_api/internal/auth"
 	"runmate_api/internal/chat"
 	"runmate_api/internal/entity"
 	"runmate_api/internal/firebase"
+	"runmate_api/internal/outbox"
+	"runmate_api/internal/reminder"
 	"runmate_api/internal/repository"
 	"runmate_api/internal/service"
+	"runmate_api/internal/storage"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -39,33 +45,112 @@ func main() {
 		&entity.ChallengeEvent{},
 		&entity.Message{},
 		&entity.Event{},
+		&entity.OutboxNotification{},
+		&entity.PrivacyZone{},
+		&entity.Kudos{},
+		&entity.Comment{},
+		&entity.ActivityMedia{},
+		&entity.BestEffort{},
+		&entity.Reminder{},
+		&entity.Goal{},
+		&entity.StreakFreeze{},
+		&entity.XPTransaction{},
+		&entity.LevelUp{},
 	)
 	if err != nil {
 		log.Fatalf("failed to migrate database %v", err)
 	}
 
+	var blobStorage storage.Storage
+	var localStorage *storage.Local
+	if config.StorageDriver() == config.StorageS3 {
+		blobStorage = storage.NewS3(storage.S3Config{
+			Endpoint:        config.S3Endpoint(),
+			Region:          config.S3Region(),
+			Bucket:          config.S3Bucket(),
+			AccessKeyID:     config.S3AccessKeyID(),
+			SecretAccessKey: config.S3SecretAccessKey(),
+			PublicURL:       config.S3PublicURL(),
+		})
+	} else {
+		localStorage, err = storage.NewLocal(config.MediaDir(), config.MediaBaseURL())
+		if err != nil {
+			log.Fatalf("failed to initialize storage %v", err)
+		}
+		blobStorage = localStorage
+	}
+
 	firebaseClient, err := firebase.NewClient()
 	if err != nil {
 		log.Fatalf("failed to initialize firebase client %v", err)
 	}
 
+	tokenManager, err := auth.NewTokenManager(config.JWTSecret())
+	if err != nil {
+		log.Fatalf("failed to initialize token manager %v", err)
+	}
+
+	levelRewards, err := service.NewLevelRewards(config.LevelRewards())
+	if err != nil {
+		log.Fatalf("failed to load level rewards %v", err)
+	}
+
 	activityRepo := repository.NewActivity(db)
 	challengeRepo := repository.NewChallenge(db)
+	commentRepo := repository.NewComment(db)
 	eventRepo := repository.NewEvent(db)
+	goalRepo := repository.NewGoal(db)
+	kudosRepo := repository.NewKudos(db)
+	levelUpRepo := repository.NewLevelUp(db)
+	mediaRepo := repository.NewMedia(db)
 	messageRepo := repository.NewMessage(db)
+	outboxRepo := repository.NewOutbox(db)
+	privacyZoneRepo := repository.NewPrivacyZone(db)
+	recordRepo := repository.NewRecord(db)
+	reminderRepo := repository.NewReminder(db)
+	streakRepo := repository.NewStreak(db)
 	userRepo := repository.NewUser(db)
+	xpRepo := repository.NewXP(db)
+	transactor := repository.NewTransactor(db)
+
+	err = goalRepo.MigrateLegacy(context.Background())
+	if err != nil {
+		log.Fatalf("failed to migrate database %v", err)
+	}
 
-	activityService := service.NewActivity(activityRepo, challengeRepo, userRepo, firebaseClient)
+	err = xpRepo.MigrateBalances(context.Background())
+	if err != nil {
+		log.Fatalf("failed to migrate database %v", err)
+	}
+
+	err = levelUpRepo.MigrateDates(context.Background())
+	if err != nil {
+		log.Fatalf("failed to migrate database %v", err)
+	}
+
+	activityService := service.NewActivity(activityRepo, challengeRepo, goalRepo, levelUpRepo, recordRepo, streakRepo, userRepo, xpRepo, outboxRepo, transactor, blobStorage, levelRewards)
 	challengeService := service.NewChallenge(challengeRepo, userRepo)
 	eventService := service.NewEvent(eventRepo, userRepo, firebaseClient)
+	goalService := service.NewGoal(activityRepo, goalRepo, reminderRepo, userRepo, outboxRepo, transactor)
+	mediaService := service.NewMedia(activityRepo, mediaRepo, blobStorage)
 	messageService := service.NewMessage(challengeRepo, messageRepo, userRepo, firebaseClient)
-	userService := service.NewUser(activityRepo, userRepo)
+	recordService := service.NewRecord(recordRepo, userRepo)
+	socialService := service.NewSocial(activityRepo, kudosRepo, commentRepo, outboxRepo, transactor)
+	streakService := service.NewStreak(activityRepo, reminderRepo, streakRepo, userRepo, outboxRepo, transactor)
+	userService := service.NewUser(activityRepo, goalRepo, privacyZoneRepo, streakRepo, userRepo, tokenManager, levelRewards)
+	xpService := service.NewXP(levelUpRepo, userRepo, xpRepo, outboxRepo, transactor, levelRewards)
+
+	outboxDispatcher := outbox.NewDispatcher(outboxRepo, firebaseClient)
+	outboxDispatcher.Start(context.Background())
+
+	reminderScheduler := reminder.NewScheduler(goalService, streakService)
+	reminderScheduler.Start(context.Background())
 
 	chatHub := chat.NewHub()
 	chatConsumer := chat.NewConsumer(chatHub, messageService, userService)
 
-	adm := handler.NewADM(activityService, challengeService, eventService, userService, firebaseClient)
-	api := handler.NewAPI(activityService, challengeService, eventService, userService)
+	adm := handler.NewADM(activityService, challengeService, eventService, userService, xpService, firebaseClient)
+	api := handler.NewAPI(activityService, challengeService, eventService, goalService, mediaService, recordService, socialService, userService, xpService)
 	chat := handler.NewChat(activityService, challengeService, messageService, userService, chatHub, chatConsumer)
 
 	r := chi.NewRouter()
@@ -73,11 +158,16 @@ func main() {
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"https://*", "http://*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "Idempotency-Key", "X-CSRF-Token"},
 		AllowCredentials: false,
 		MaxAge:           300, // Maximum value not ignored by any of major browsers
 	}))
 
+	if localStorage != nil {
+		mediaAccess := handler.MediaAccess(mediaService, "/media")
+		r.With(handler.Authenticator(userService), mediaAccess).Handle("/media/*", http.StripPrefix("/media", localStorage.Handler()))
+	}
+
 	adm.Routes(r)
 	api.Routes(r)
 	chat.Routes(r)
@@ -81,3 +81,66 @@ func KafkaPassword() string {
 func FirebaseCredentials() []byte {
 	return []byte(os.Getenv("FIREBASE_CREDENTIALS"))
 }
+
+func JWTSecret() []byte {
+	return []byte(os.Getenv("JWT_SECRET"))
+}
+
+// LevelRewards is the JSON reward table of the levels, or empty for the
+// default one.
+func LevelRewards() []byte {
+	return []byte(os.Getenv("LEVEL_REWARDS"))
+}
+
+const (
+	StorageLocal = "local"
+	StorageS3    = "s3"
+)
+
+// StorageDriver chooses where the uploaded photos are kept: StorageLocal
+// (default) or StorageS3.
+func StorageDriver() string {
+	if driver := os.Getenv("STORAGE_DRIVER"); driver != "" {
+		return driver
+	}
+	return StorageLocal
+}
+
+func MediaDir() string {
+	if dir := os.Getenv("MEDIA_DIR"); dir != "" {
+		return dir
+	}
+	return "./media"
+}
+
+// MediaBaseURL is the address the API serves the local storage from.
+func MediaBaseURL() string {
+	if url := os.Getenv("MEDIA_BASE_URL"); url != "" {
+		return url
+	}
+	return fmt.Sprintf("http://localhost:%s/media", APIPort())
+}
+
+func S3Endpoint() string {
+	return os.Getenv("S3_ENDPOINT")
+}
+
+func S3Region() string {
+	return os.Getenv("S3_REGION")
+}
+
+func S3Bucket() string {
+	return os.Getenv("S3_BUCKET")
+}
+
+func S3AccessKeyID() string {
+	return os.Getenv("S3_ACCESS_KEY_ID")
+}
+
+func S3SecretAccessKey() string {
+	return os.Getenv("S3_SECRET_ACCESS_KEY")
+}
+
+func S3PublicURL() string {
+	return os.Getenv("S3_PUBLIC_URL")
+}
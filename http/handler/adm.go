@@ -16,6 +16,7 @@ type adm struct {
 	challengeService *service.Challenge
 	eventService     *service.Event
 	userService      *service.User
+	xpService        *service.XP
 
 	firebaseClient *firebase.Client
 }
@@ -25,6 +26,7 @@ func NewADM(
 	challengeService *service.Challenge,
 	eventService *service.Event,
 	userService *service.User,
+	xpService *service.XP,
 	firebaseClient *firebase.Client,
 ) *adm {
 	return &adm{
@@ -32,6 +34,7 @@ func NewADM(
 		challengeService: challengeService,
 		eventService:     eventService,
 		userService:      userService,
+		xpService:        xpService,
 
 		firebaseClient: firebaseClient,
 	}
@@ -39,7 +42,16 @@ func NewADM(
 
 func (a *adm) Routes(r *chi.Mux) {
 	r.Route("/adm", func(r chi.Router) {
+		r.Use(Authenticator(a.userService), RequireAdmin)
 		r.Post("/notify", a.notify)
+
+		r.Route("/activities", func(r chi.Router) {
+			r.Get("/review", a.getActivitiesPendingReview)
+			r.Put("/{id}/approve", a.approveActivity)
+			r.Put("/{id}/reject", a.rejectActivity)
+		})
+
+		r.Post("/users/{id}/xp", a.adjustUserXP)
 	})
 }
 
@@ -53,9 +65,75 @@ func (a *adm) notify(w http.ResponseWriter, r *http.Request) {
 
 	err = a.firebaseClient.SendNotification(r.Context(), &firebase.Notification{Title: input.Title, Body: input.Body}, input.Tokens)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (a *adm) getActivitiesPendingReview(w http.ResponseWriter, r *http.Request) {
+	activities, err := a.activityService.ListPendingReview(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	result := make([]*model.Activity, 0, len(activities))
+	for _, activity := range activities {
+		result = append(result, model.NewActivityFromEntity(activity, model.TrackDetailSimplified))
+	}
+
+	err = json.NewEncoder(w).Encode(result)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *adm) approveActivity(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	err := a.activityService.Approve(r.Context(), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *adm) rejectActivity(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	err := a.activityService.Reject(r.Context(), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *adm) adjustUserXP(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	var input model.AdjustXPInput
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	transaction, err := a.xpService.Adjust(r.Context(), id, input.Amount, input.Reason)
+	if err != nil {
+		writeError(w, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusCreated)
+	err = json.NewEncoder(w).Encode(model.NewXPTransactionFromEntity(transaction))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
 }
@@ -3,52 +3,107 @@ package handler
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 
 	"runmate_api/http/model"
 	"runmate_api/internal/entity"
+	"runmate_api/internal/media"
 	"runmate_api/internal/service"
+	"runmate_api/internal/track"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/google/uuid"
+)
+
+const (
+	maxTrackFileSize = 20 << 20
+	// maxMediaRequestSize leaves room for the multipart overhead around the
+	// image.
+	maxMediaRequestSize = media.MaxSize + 1<<20
 )
 
 type api struct {
 	activityService  *service.Activity
 	challengeService *service.Challenge
 	eventService     *service.Event
+	goalService      *service.Goal
+	mediaService     *service.Media
+	recordService    *service.Record
+	socialService    *service.Social
 	userService      *service.User
+	xpService        *service.XP
 }
 
 func NewAPI(
 	activityService *service.Activity,
 	challengeService *service.Challenge,
 	eventService *service.Event,
+	goalService *service.Goal,
+	mediaService *service.Media,
+	recordService *service.Record,
+	socialService *service.Social,
 	userService *service.User,
+	xpService *service.XP,
 ) *api {
 	return &api{
 		activityService:  activityService,
 		challengeService: challengeService,
 		eventService:     eventService,
+		goalService:      goalService,
+		mediaService:     mediaService,
+		recordService:    recordService,
+		socialService:    socialService,
 		userService:      userService,
+		xpService:        xpService,
 	}
 }
 
 func (a *api) Routes(r *chi.Mux) {
+	authenticator := Authenticator(a.userService)
+
 	r.Route("/activities", func(r chi.Router) {
+		r.Use(authenticator)
 		r.Get("/", a.getActivities)
 		r.Post("/", a.createActivity)
+		r.Post("/import", a.importActivity)
+		r.Get("/{id}/export", a.exportActivity)
+		r.Get("/{id}/splits", a.getActivitySplits)
+		r.Put("/{id}", a.updateActivity)
 		r.Delete("/{id}", a.deleteActivity)
+
+		r.Route("/{id}/media", func(r chi.Router) {
+			r.Post("/", a.uploadActivityMedia)
+			r.Delete("/{mediaID}", a.deleteActivityMedia)
+		})
+
+		r.Route("/{id}/kudos", func(r chi.Router) {
+			r.Get("/", a.listKudos)
+			r.Post("/", a.giveKudos)
+			r.Delete("/", a.removeKudos)
+		})
+
+		r.Route("/{id}/comments", func(r chi.Router) {
+			r.Get("/", a.listComments)
+			r.Post("/", a.createComment)
+			r.Delete("/{commentID}", a.deleteComment)
+		})
 	})
 
+	r.With(authenticator).Get("/feed", a.getFeed)
+
 	r.Route("/challenges", func(r chi.Router) {
+		r.Use(authenticator)
 		r.Post("/", a.createChallenge)
 		r.Get("/", a.getChallenges)
 		r.Get("/{id}", a.getChallenge)
+		r.Put("/{id}", a.updateChallenge)
 		r.Put("/join", a.joinChallenge)
 	})
 
 	r.Route("/events", func(r chi.Router) {
+		r.Use(authenticator)
 		r.Post("/", a.createEvent)
 		r.Get("/", a.getEvents)
 		r.Get("/{id}", a.getEvent)
@@ -57,38 +112,59 @@ func (a *api) Routes(r *chi.Mux) {
 	})
 
 	r.Route("/friends", func(r chi.Router) {
+		r.Use(authenticator)
 		r.Post("/", a.addFriend)
 		r.Delete("/", a.removeFriend)
 	})
 
 	r.Route("/users", func(r chi.Router) {
 		r.Post("/", a.createUser)
-		r.Get("/", a.getUsers)
-		r.Get("/{username:[a-zA-Z0-9_]+}", a.getUserByUsername)
-		r.Get("/{id:[a-zA-Z0-9\\-]{36}}", a.getUserByID)
-		r.Put("/{id}", a.updateUser)
-		r.Delete("/{id}", a.deleteUser)
 
-		r.Get("/{id}/activities", a.getUserActivities)
+		r.Group(func(r chi.Router) {
+			r.Use(authenticator)
+			r.Get("/", a.getUsers)
+			r.Get("/{username:[a-zA-Z0-9_]+}", a.getUserByUsername)
+			r.Get("/{id:[a-zA-Z0-9\\-]{36}}", a.getUserByID)
+			r.Put("/{id}", a.updateUser)
+			r.Delete("/{id}", a.deleteUser)
 
-		r.Get("/{id}/events", a.getUserEvents)
+			r.Get("/{id}/activities", a.getUserActivities)
 
-		r.Get("/{id}/challenges", a.getUserChallenges)
+			r.Get("/{id}/records", a.getUserRecords)
 
-		r.Put("/{id}/fcm", a.updateUserFCM)
+			r.Get("/{id}/stats", a.getUserStats)
 
-		r.Route("/{id}/friends", func(r chi.Router) {
-			r.Get("/", a.listFriends)
-			r.Get("/activities", a.listFriendsActivities)
-		})
+			r.Get("/{id}/xp", a.getUserXP)
+
+			r.Get("/{id}/events", a.getUserEvents)
+
+			r.Get("/{id}/challenges", a.getUserChallenges)
+
+			r.Put("/{id}/fcm", a.updateUserFCM)
 
-		r.Route("/{id}/goal", func(r chi.Router) {
-			r.Put("/", a.updateUserGoal)
-			r.Delete("/", a.deleteUserGoal)
+			r.Put("/{id}/frame", a.updateUserProfileFrame)
+
+			r.Route("/{id}/friends", func(r chi.Router) {
+				r.Get("/", a.listFriends)
+				r.Get("/activities", a.listFriendsActivities)
+			})
+
+			r.Route("/{id}/goals", func(r chi.Router) {
+				r.Get("/", a.listGoals)
+				r.Post("/", a.createGoal)
+				r.Delete("/{goalID}", a.endGoal)
+			})
+
+			r.Route("/{id}/privacy-zones", func(r chi.Router) {
+				r.Get("/", a.listPrivacyZones)
+				r.Post("/", a.createPrivacyZone)
+				r.Delete("/{zoneID}", a.deletePrivacyZone)
+			})
 		})
 	})
 
 	r.Post("/login", a.login)
+	r.Post("/refresh", a.refresh)
 }
 
 func (a *api) createActivity(w http.ResponseWriter, r *http.Request) {
@@ -99,36 +175,199 @@ func (a *api) createActivity(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	activity, err := input.ToEntity()
+	activity, err := input.ToEntity(currentUser(r).ID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	err = a.activityService.Create(r.Context(), activity)
+	a.saveActivity(w, r, activity)
+}
+
+// saveActivity creates the activity and answers with the stored one. Retries
+// of an already stored activity answer 200 instead of 201.
+func (a *api) saveActivity(w http.ResponseWriter, r *http.Request, activity *entity.Activity) {
+	detail, err := model.ParseTrackDetail(r.URL.Query().Get("track"))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	w.WriteHeader(http.StatusCreated)
+	if key := r.Header.Get("Idempotency-Key"); key != "" {
+		activity.IdempotencyKey = &key
+	}
+
+	created, err := a.activityService.Create(r.Context(), activity)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+
+	w.WriteHeader(status)
+	err = json.NewEncoder(w).Encode(model.NewActivityFromEntity(activity, detail))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+}
+
+func (a *api) importActivity(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxTrackFileSize)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	format, err := track.FormatFromFilename(header.Filename)
+	if value := r.FormValue("format"); value != "" {
+		format, err = track.ParseFormat(value)
+	}
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	visibility, err := model.ActivityVisibility(r.FormValue("visibility")).ToEntity()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	activity, err := track.Parse(file, format)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	activity.UserID = currentUser(r).ID
+	activity.Visibility = visibility
+	if title := r.FormValue("title"); title != "" {
+		activity.Title = title
+	}
+
+	if activityType := r.FormValue("type"); activityType != "" {
+		activity.Type, err = model.ActivityType(activityType).ToEntity()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	a.saveActivity(w, r, activity)
+}
+
+func (a *api) exportActivity(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	format, err := track.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	activity, err := a.activityService.GetByID(r.Context(), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", activity.ID.String()+"."+string(format)))
+	err = track.Encode(w, activity, format)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+}
+
+func (a *api) getActivitySplits(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	unit, err := model.ParseSplitUnit(r.URL.Query().Get("unit"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	splits, err := a.activityService.Splits(r.Context(), id, unit.Meters())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(model.NewSplitsFromEntity(splits, unit))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
 }
 
 func (a *api) getActivities(w http.ResponseWriter, r *http.Request) {
-	activities, err := a.activityService.ListAll(r.Context())
+	filter, err := model.NewActivityFilterFromQuery(r.URL.Query())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	result := make([]*model.Activity, 0, len(activities))
-	for _, activity := range activities {
-		result = append(result, model.NewActivityFromEntity(activity))
+	detail, err := model.ParseTrackDetail(r.URL.Query().Get("track"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
-	err = json.NewEncoder(w).Encode(result)
+	page, err := a.activityService.ListAll(r.Context(), filter)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(model.NewActivityPageFromEntity(page, detail))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *api) updateActivity(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	detail, err := model.ParseTrackDetail(r.URL.Query().Get("track"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var input model.UpdateActivityInput
+	err = json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	activity, err := input.ToEntity(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = a.activityService.Update(r.Context(), activity)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(model.NewActivityFromEntity(activity, detail))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -139,7 +378,164 @@ func (a *api) deleteActivity(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	err := a.activityService.Delete(r.Context(), id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *api) uploadActivityMedia(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	r.Body = http.MaxBytesReader(w, r.Body, maxMediaRequestSize)
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		writeUploadError(w, err)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, media.MaxSize+1))
+	if err != nil {
+		writeUploadError(w, err)
+		return
+	}
+
+	activityMedia, err := a.mediaService.Upload(r.Context(), id, data)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	err = json.NewEncoder(w).Encode(model.NewMediaFromEntity(activityMedia))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+}
+
+// writeUploadError answers 413, like media.ErrTooLarge, when the upload is
+// cut by its size limit, and 400 otherwise.
+func writeUploadError(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		http.Error(w, media.ErrTooLarge.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	http.Error(w, err.Error(), http.StatusBadRequest)
+}
+
+func (a *api) deleteActivityMedia(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	mediaID := chi.URLParam(r, "mediaID")
+	err := a.mediaService.Delete(r.Context(), id, mediaID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *api) listKudos(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	kudos, err := a.socialService.ListKudos(r.Context(), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	result := make([]*model.Kudos, 0, len(kudos))
+	for _, k := range kudos {
+		result = append(result, model.NewKudosFromEntity(k))
+	}
+
+	err = json.NewEncoder(w).Encode(result)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *api) giveKudos(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	err := a.socialService.GiveKudos(r.Context(), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *api) removeKudos(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	err := a.socialService.RemoveKudos(r.Context(), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *api) listComments(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	comments, err := a.socialService.ListComments(r.Context(), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(model.NewCommentThreadsFromEntity(comments))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *api) createComment(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	var input model.CreateCommentInput
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	comment, err := input.ToEntity()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = a.socialService.CreateComment(r.Context(), id, comment)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	comment.User = currentUser(r)
+	w.WriteHeader(http.StatusCreated)
+	err = json.NewEncoder(w).Encode(model.NewCommentFromEntity(comment))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+}
+
+func (a *api) deleteComment(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	commentID := chi.URLParam(r, "commentID")
+	err := a.socialService.DeleteComment(r.Context(), id, commentID)
+	if err != nil {
+		writeError(w, err)
 		return
 	}
 
@@ -148,20 +544,27 @@ func (a *api) deleteActivity(w http.ResponseWriter, r *http.Request) {
 
 func (a *api) getUserActivities(w http.ResponseWriter, r *http.Request) {
 	userID := chi.URLParam(r, "id")
-	activities, err := a.activityService.ListByUser(r.Context(), userID)
+	filter, err := model.NewActivityFilterFromQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	detail, err := model.ParseTrackDetail(r.URL.Query().Get("track"))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	result := make([]*model.Activity, 0, len(activities))
-	for _, activity := range activities {
-		result = append(result, model.NewActivityFromEntity(activity))
+	page, err := a.activityService.ListByUser(r.Context(), userID, filter)
+	if err != nil {
+		writeError(w, err)
+		return
 	}
 
-	err = json.NewEncoder(w).Encode(result)
+	err = json.NewEncoder(w).Encode(model.NewActivityPageFromEntity(page, detail))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -182,7 +585,7 @@ func (a *api) createChallenge(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	challenge, err := input.ToEntity()
+	challenge, err := input.ToEntity(currentUser(r).ID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -190,13 +593,13 @@ func (a *api) createChallenge(w http.ResponseWriter, r *http.Request) {
 
 	err = a.challengeService.Create(r.Context(), challenge)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
 	err = json.NewEncoder(w).Encode(model.NewChallengeFromEntity(challenge, nil))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -213,7 +616,7 @@ func (a *api) getChallenges(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -224,7 +627,7 @@ func (a *api) getChallenges(w http.ResponseWriter, r *http.Request) {
 
 	err = json.NewEncoder(w).Encode(result)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -235,7 +638,7 @@ func (a *api) getChallenge(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	challenge, err := a.challengeService.GetByID(r.Context(), id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -246,13 +649,43 @@ func (a *api) getChallenge(w http.ResponseWriter, r *http.Request) {
 
 	ranking, err := a.challengeService.GetRanking(r.Context(), challenge)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
 	err = json.NewEncoder(w).Encode(model.NewChallengeFromEntity(challenge, ranking))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *api) updateChallenge(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	var input model.UpdateChallengeInput
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	challenge, err := input.ToEntity(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = a.challengeService.Update(r.Context(), challenge)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(model.NewChallengeFromEntity(challenge, nil))
+	if err != nil {
+		writeError(w, err)
 		return
 	}
 
@@ -267,9 +700,9 @@ func (a *api) joinChallenge(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = a.challengeService.Join(r.Context(), input.ChallengeID, input.UserID)
+	err = a.challengeService.Join(r.Context(), input.ChallengeID, currentUser(r).ID.String())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -286,7 +719,7 @@ func (a *api) getUserChallenges(w http.ResponseWriter, r *http.Request) {
 
 	challenges, err := listChallengesFunc(r.Context(), userID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -294,7 +727,7 @@ func (a *api) getUserChallenges(w http.ResponseWriter, r *http.Request) {
 	for _, challenge := range challenges {
 		ranking, err := a.challengeService.GetRanking(r.Context(), challenge)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			writeError(w, err)
 			return
 		}
 
@@ -303,7 +736,7 @@ func (a *api) getUserChallenges(w http.ResponseWriter, r *http.Request) {
 
 	err = json.NewEncoder(w).Encode(result)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -324,21 +757,21 @@ func (a *api) createEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	event, err := input.ToEntity()
+	event, err := input.ToEntity(currentUser(r).ID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
 	err = a.eventService.Create(r.Context(), event)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
 	err = json.NewEncoder(w).Encode(model.NewEventFromEntity(event))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -355,7 +788,7 @@ func (a *api) getEvents(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -366,7 +799,7 @@ func (a *api) getEvents(w http.ResponseWriter, r *http.Request) {
 
 	err = json.NewEncoder(w).Encode(result)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -377,13 +810,13 @@ func (a *api) getEvent(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	event, err := a.eventService.GetByID(r.Context(), id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
 	err = json.NewEncoder(w).Encode(model.NewEventFromEntity(event))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -398,9 +831,9 @@ func (a *api) joinEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = a.eventService.Join(r.Context(), input.EventID, input.UserID)
+	err = a.eventService.Join(r.Context(), input.EventID, currentUser(r).ID.String())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -415,9 +848,9 @@ func (a *api) quitEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = a.eventService.Quit(r.Context(), input.EventID, input.UserID)
+	err = a.eventService.Quit(r.Context(), input.EventID, currentUser(r).ID.String())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -434,7 +867,7 @@ func (a *api) getUserEvents(w http.ResponseWriter, r *http.Request) {
 
 	events, err := listEventsFunc(r.Context(), userID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -445,7 +878,7 @@ func (a *api) getUserEvents(w http.ResponseWriter, r *http.Request) {
 
 	err = json.NewEncoder(w).Encode(result)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -460,17 +893,21 @@ func (a *api) createUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user := input.ToEntity()
+	user, err := input.ToEntity()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	err = a.userService.Create(r.Context(), user)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
 	err = json.NewEncoder(w).Encode(model.NewUserFromEntity(user))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -487,7 +924,7 @@ func (a *api) getUsers(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -498,7 +935,7 @@ func (a *api) getUsers(w http.ResponseWriter, r *http.Request) {
 
 	err = json.NewEncoder(w).Encode(result)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -507,9 +944,9 @@ func (a *api) getUsers(w http.ResponseWriter, r *http.Request) {
 
 func (a *api) getUserByID(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
-	user, err := a.userService.GetByID(r.Context(), id)
+	user, err := a.userService.GetProfile(r.Context(), id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -521,7 +958,7 @@ func (a *api) getUserByID(w http.ResponseWriter, r *http.Request) {
 
 	err = json.NewEncoder(w).Encode(result)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -532,7 +969,7 @@ func (a *api) getUserByUsername(w http.ResponseWriter, r *http.Request) {
 	username := chi.URLParam(r, "username")
 	user, err := a.userService.GetByUsername(r.Context(), username)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -544,7 +981,7 @@ func (a *api) getUserByUsername(w http.ResponseWriter, r *http.Request) {
 
 	err = json.NewEncoder(w).Encode(result)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -553,27 +990,22 @@ func (a *api) getUserByUsername(w http.ResponseWriter, r *http.Request) {
 
 func (a *api) updateUser(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
-	var input model.CreateUserInput
+	var input model.UpdateUserInput
 	err := json.NewDecoder(r.Body).Decode(&input)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	user := input.ToEntity()
-	user.ID, err = uuid.Parse(id)
+	user, weekStart, err := input.ToEntity(id)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	err = a.userService.Update(r.Context(), user)
+	err = a.userService.Update(r.Context(), user, weekStart)
 	if err != nil {
-		if errors.Is(err, service.ErrUserNotFound) {
-			http.Error(w, err.Error(), http.StatusNotFound)
-			return
-		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -591,41 +1023,205 @@ func (a *api) updateUserFCM(w http.ResponseWriter, r *http.Request) {
 
 	err = a.userService.UpdateFCMToken(r.Context(), id, input.Token)
 	if err != nil {
-		if errors.Is(err, service.ErrUserNotFound) {
-			http.Error(w, err.Error(), http.StatusNotFound)
-			return
-		}
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *api) updateUserProfileFrame(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	var input model.UpdateUserProfileFrameInput
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = a.userService.UpdateProfileFrame(r.Context(), id, input.Frame)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// listGoals answers the active goals of the user or, with ended=1, also the
+// ended ones.
+func (a *api) listGoals(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	goals, err := a.goalService.ListByUser(r.Context(), id, r.URL.Query().Get("ended") == "1")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
 
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	err = json.NewEncoder(w).Encode(model.NewGoalsFromEntity(goals))
+	if err != nil {
+		writeError(w, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 }
 
-func (a *api) updateUserGoal(w http.ResponseWriter, r *http.Request) {
+func (a *api) createGoal(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
-	var input model.UpdateUserGoalInput
+	var input model.CreateGoalInput
 	err := json.NewDecoder(r.Body).Decode(&input)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	err = a.userService.UpdateGoal(r.Context(), id, &input.Days, &input.DailyDistance)
+	goal, err := input.ToEntity(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = a.goalService.Create(r.Context(), goal)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	err = json.NewEncoder(w).Encode(model.NewGoalFromEntity(goal))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+}
+
+func (a *api) endGoal(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	goalID := chi.URLParam(r, "goalID")
+	err := a.goalService.End(r.Context(), id, goalID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *api) getUserStats(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	types, err := model.ParseActivityTypes(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	stats, err := a.userService.Stats(r.Context(), id, r.URL.Query().Get("tz"), types)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(model.NewUserStatsFromEntity(stats))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *api) getUserRecords(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	records, err := a.recordService.ListByUser(r.Context(), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(model.NewPersonalRecordsFromEntity(records))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 }
 
-func (a *api) deleteUserGoal(w http.ResponseWriter, r *http.Request) {
+func (a *api) getUserXP(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
-	err := a.userService.UpdateGoal(r.Context(), id, nil, nil)
+	user, err := a.xpService.GetLedger(r.Context(), id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(model.NewXPLedgerFromEntity(user))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *api) listPrivacyZones(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	zones, err := a.userService.ListPrivacyZones(r.Context(), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	result := make([]*model.PrivacyZone, 0, len(zones))
+	for _, zone := range zones {
+		result = append(result, model.NewPrivacyZoneFromEntity(zone))
+	}
+
+	err = json.NewEncoder(w).Encode(result)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *api) createPrivacyZone(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	var input model.CreatePrivacyZoneInput
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	zone, err := input.ToEntity(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = a.userService.CreatePrivacyZone(r.Context(), zone)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	err = json.NewEncoder(w).Encode(model.NewPrivacyZoneFromEntity(zone))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+}
+
+func (a *api) deletePrivacyZone(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	zoneID := chi.URLParam(r, "zoneID")
+	err := a.userService.DeletePrivacyZone(r.Context(), id, zoneID)
+	if err != nil {
+		writeError(w, err)
 		return
 	}
 
@@ -636,7 +1232,7 @@ func (a *api) deleteUser(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	err := a.userService.Delete(r.Context(), id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -651,9 +1247,9 @@ func (a *api) addFriend(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = a.userService.AddFriend(r.Context(), input.UserID, input.FriendID)
+	err = a.userService.AddFriend(r.Context(), currentUser(r).ID.String(), input.FriendID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -664,7 +1260,7 @@ func (a *api) listFriends(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	friends, err := a.userService.ListFriends(r.Context(), id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -675,7 +1271,7 @@ func (a *api) listFriends(w http.ResponseWriter, r *http.Request) {
 
 	err = json.NewEncoder(w).Encode(result)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -683,21 +1279,45 @@ func (a *api) listFriends(w http.ResponseWriter, r *http.Request) {
 }
 
 func (a *api) listFriendsActivities(w http.ResponseWriter, r *http.Request) {
-	id := chi.URLParam(r, "id")
-	activities, err := a.activityService.ListAllFromUserFriends(r.Context(), id)
+	a.writeFeed(w, r, chi.URLParam(r, "id"), false)
+}
+
+func (a *api) getFeed(w http.ResponseWriter, r *http.Request) {
+	a.writeFeed(w, r, currentUser(r).ID.String(), true)
+}
+
+// writeFeed answers with a page of the user's feed. The include_own query
+// parameter overrides whether the user's own activities are part of it.
+func (a *api) writeFeed(w http.ResponseWriter, r *http.Request, userID string, includeOwn bool) {
+	filter, err := model.NewActivityFilterFromQuery(r.URL.Query())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	result := make([]*model.Activity, 0, len(activities))
-	for _, activity := range activities {
-		result = append(result, model.NewActivityFromEntity(activity))
+	detail, err := model.ParseTrackDetail(r.URL.Query().Get("track"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
-	err = json.NewEncoder(w).Encode(result)
+	if value := r.URL.Query().Get("include_own"); value != "" {
+		includeOwn, err = strconv.ParseBool(value)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	page, err := a.activityService.ListFeed(r.Context(), userID, includeOwn, filter)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(model.NewActivityPageFromEntity(page, detail))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -712,9 +1332,9 @@ func (a *api) removeFriend(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = a.userService.RemoveFriend(r.Context(), input.UserID, input.FriendID)
+	err = a.userService.RemoveFriend(r.Context(), currentUser(r).ID.String(), input.FriendID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -729,22 +1349,48 @@ func (a *api) login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := a.userService.Authenticate(r.Context(), input.Username, input.Password)
+	user, tokens, err := a.userService.Login(r.Context(), input.Username, input.Password)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		if errors.Is(err, service.ErrInvalidCredentials) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		writeError(w, err)
 		return
 	}
 
-	if user == nil {
+	err = json.NewEncoder(w).Encode(&model.LoginOutput{
+		Tokens: model.NewTokensFromAuth(tokens),
+		User:   model.NewUserFromEntity(user),
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *api) refresh(w http.ResponseWriter, r *http.Request) {
+	var input model.RefreshTokenInput
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tokens, err := a.userService.RefreshTokens(r.Context(), input.RefreshToken)
+	if err != nil {
 		http.Error(w, "unauthorized", http.StatusUnauthorized)
 		return
 	}
 
-	err = json.NewEncoder(w).Encode(model.NewUserFromEntity(user))
+	err = json.NewEncoder(w).Encode(model.NewTokensFromAuth(tokens))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	w.WriteHeader(http.StatusOK)
 }
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"runmate_api/internal/auth"
+	"runmate_api/internal/entity"
+	"runmate_api/internal/service"
+)
+
+func Authenticator(userService *service.User) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || token == "" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			user, err := userService.GetByAccessToken(r.Context(), token)
+			if err != nil {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.WithUser(r.Context(), user)))
+		})
+	}
+}
+
+func RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := service.AuthorizeAdmin(r.Context())
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func currentUser(r *http.Request) *entity.User {
+	user, _ := auth.UserFromContext(r.Context())
+	return user
+}
+
+// MediaAccess lets through only the requests for files of activities the
+// caller may see. The file key is the path under prefix.
+func MediaAccess(mediaService *service.Media, prefix string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := strings.TrimPrefix(r.URL.Path, prefix+"/")
+			err := mediaService.AuthorizeFile(r.Context(), key)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -46,6 +46,7 @@ func NewChat(
 
 func (c *chatHandler) Routes(r *chi.Mux) {
 	r.Route("/chat", func(r chi.Router) {
+		r.Use(Authenticator(c.userService))
 		r.Get("/{id}", c.handle)
 		r.Get("/{id}/messages", c.getMessages)
 	})
@@ -74,13 +75,20 @@ func (c *chatHandler) handle(w http.ResponseWriter, r *http.Request) {
 		conn.Close()
 	}()
 
+	sender := currentUser(r)
 	for {
-		_, msg, err := conn.ReadMessage()
+		_, data, err := conn.ReadMessage()
 		if err != nil {
 			log.Println("WebSocket read error:", err)
 			break
 		}
 
+		msg, err := chat.NewUserMessage(data, sender.ID)
+		if err != nil {
+			log.Println("Invalid chat message:", err)
+			continue
+		}
+
 		publisher.Publish(msg)
 	}
 }
@@ -90,7 +98,7 @@ func (c *chatHandler) getMessages(w http.ResponseWriter, r *http.Request) {
 
 	messages, err := c.messageService.ListByChallengeID(r.Context(), id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -98,7 +106,7 @@ func (c *chatHandler) getMessages(w http.ResponseWriter, r *http.Request) {
 	for _, message := range messages {
 		user, err := c.userService.GetByID(r.Context(), message.UserID.String())
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			writeError(w, err)
 			return
 		}
 
@@ -107,7 +115,7 @@ func (c *chatHandler) getMessages(w http.ResponseWriter, r *http.Request) {
 
 	err = json.NewEncoder(w).Encode(result)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"runmate_api/internal/entity"
+	"runmate_api/internal/geo"
+	"runmate_api/internal/media"
+	"runmate_api/internal/service"
+)
+
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, entity.ErrCoordinateTimeNotMonotonic),
+		errors.Is(err, entity.ErrNegativeActivityMeasure),
+		errors.Is(err, entity.ErrInvalidPrivacyZone),
+		errors.Is(err, entity.ErrInvalidComment),
+		errors.Is(err, entity.ErrInvalidTimeZone),
+		errors.Is(err, entity.ErrInvalidWeekStart),
+		errors.Is(err, entity.ErrInvalidGoal),
+		errors.Is(err, entity.ErrInvalidXPAdjustment),
+		errors.Is(err, entity.ErrInvalidProfileFrame):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, service.ErrActivityDistanceMismatch),
+		errors.Is(err, service.ErrActivityDurationMismatch),
+		errors.Is(err, geo.ErrNoTimestamps):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case errors.Is(err, media.ErrTooLarge):
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+	case errors.Is(err, media.ErrUnsupportedType):
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+	case errors.Is(err, service.ErrActivityNotPendingReview),
+		errors.Is(err, service.ErrMediaLimitReached),
+		errors.Is(err, service.ErrGoalLimitReached):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, service.ErrUnauthenticated):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	case errors.Is(err, service.ErrForbidden):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, service.ErrUserNotFound),
+		errors.Is(err, service.ErrActivityNotFound),
+		errors.Is(err, service.ErrChallengeNotFound),
+		errors.Is(err, service.ErrEventNotFound),
+		errors.Is(err, service.ErrCommentNotFound),
+		errors.Is(err, service.ErrMediaNotFound),
+		errors.Is(err, service.ErrGoalNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
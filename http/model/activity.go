@@ -1,35 +1,385 @@
 package model
 
 import (
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 
 	"runmate_api/internal/entity"
+	"runmate_api/internal/geo"
 )
 
+type ActivityReviewStatus string
+
+const (
+	ActivityReviewStatusApproved ActivityReviewStatus = "approved"
+	ActivityReviewStatusPending  ActivityReviewStatus = "pending"
+	ActivityReviewStatusRejected ActivityReviewStatus = "rejected"
+)
+
+func NewActivityReviewStatusFromEntity(s entity.ActivityReviewStatus) ActivityReviewStatus {
+	switch s {
+	case entity.ActivityReviewStatusPending:
+		return ActivityReviewStatusPending
+	case entity.ActivityReviewStatusRejected:
+		return ActivityReviewStatusRejected
+	default:
+		return ActivityReviewStatusApproved
+	}
+}
+
+type ActivityType string
+
+const (
+	ActivityTypeRun       ActivityType = "run"
+	ActivityTypeWalk      ActivityType = "walk"
+	ActivityTypeHike      ActivityType = "hike"
+	ActivityTypeRide      ActivityType = "ride"
+	ActivityTypeTreadmill ActivityType = "treadmill"
+)
+
+var (
+	ErrInvalidActivityType = errors.New("invalid activity type")
+)
+
+func NewActivityTypeFromEntity(t entity.ActivityType) ActivityType {
+	switch t {
+	case entity.ActivityTypeWalk:
+		return ActivityTypeWalk
+	case entity.ActivityTypeHike:
+		return ActivityTypeHike
+	case entity.ActivityTypeRide:
+		return ActivityTypeRide
+	case entity.ActivityTypeTreadmill:
+		return ActivityTypeTreadmill
+	default:
+		return ActivityTypeRun
+	}
+}
+
+func newActivityTypesFromEntity(types []entity.ActivityType) []ActivityType {
+	result := make([]ActivityType, 0, len(types))
+	for _, t := range types {
+		result = append(result, NewActivityTypeFromEntity(t))
+	}
+
+	return result
+}
+
+// ToEntity treats an empty type as a run.
+func (t ActivityType) ToEntity() (entity.ActivityType, error) {
+	switch t {
+	case "", ActivityTypeRun:
+		return entity.ActivityTypeRun, nil
+	case ActivityTypeWalk:
+		return entity.ActivityTypeWalk, nil
+	case ActivityTypeHike:
+		return entity.ActivityTypeHike, nil
+	case ActivityTypeRide:
+		return entity.ActivityTypeRide, nil
+	case ActivityTypeTreadmill:
+		return entity.ActivityTypeTreadmill, nil
+	default:
+		return 0, ErrInvalidActivityType
+	}
+}
+
+func activityTypesToEntity(types []ActivityType) ([]entity.ActivityType, error) {
+	result := make([]entity.ActivityType, 0, len(types))
+	for _, t := range types {
+		if t == "" {
+			return nil, ErrInvalidActivityType
+		}
+
+		activityType, err := t.ToEntity()
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, activityType)
+	}
+
+	return result, nil
+}
+
+const (
+	defaultActivityPageSize = 20
+	maxActivityPageSize     = 100
+)
+
+var (
+	ErrInvalidActivityCursor = errors.New("invalid activity cursor")
+	ErrInvalidPageSize       = errors.New("invalid page size")
+	ErrInvalidDate           = errors.New("invalid date")
+)
+
+func newActivityCursorFromEntity(cursor *entity.ActivityCursor) string {
+	if cursor == nil {
+		return ""
+	}
+
+	value := cursor.Date.Format(time.RFC3339Nano) + "|" + cursor.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(value))
+}
+
+func parseActivityCursor(cursor string) (*entity.ActivityCursor, error) {
+	value, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidActivityCursor
+	}
+
+	date, id, ok := strings.Cut(string(value), "|")
+	if !ok {
+		return nil, ErrInvalidActivityCursor
+	}
+
+	parsedDate, err := time.Parse(time.RFC3339Nano, date)
+	if err != nil {
+		return nil, ErrInvalidActivityCursor
+	}
+
+	parsedID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrInvalidActivityCursor
+	}
+
+	return &entity.ActivityCursor{Date: parsedDate, ID: parsedID}, nil
+}
+
+// parseDate accepts RFC 3339 timestamps and plain dates, taken as midnight UTC.
+func parseDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	date, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		date, err = time.Parse(time.DateOnly, value)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidDate, value)
+	}
+
+	return &date, nil
+}
+
+// ParseActivityTypes reads the type query parameter, given as ?type=run,ride
+// or ?type=run&type=ride.
+func ParseActivityTypes(query url.Values) ([]entity.ActivityType, error) {
+	var types []ActivityType
+	for _, value := range query["type"] {
+		for _, t := range strings.Split(value, ",") {
+			types = append(types, ActivityType(strings.TrimSpace(t)))
+		}
+	}
+
+	return activityTypesToEntity(types)
+}
+
+// NewActivityFilterFromQuery reads the listing query parameters:
+//   - type: activity types, repeated or comma separated
+//   - from, to: date range, from inclusive and to exclusive
+//   - cursor: next_cursor of the previous page
+//   - limit: page size, 20 by default and at most 100
+//   - track: none leaves the tracks out
+func NewActivityFilterFromQuery(query url.Values) (*entity.ActivityFilter, error) {
+	activityTypes, err := ParseActivityTypes(query)
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := parseDate(query.Get("from"))
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := parseDate(query.Get("to"))
+	if err != nil {
+		return nil, err
+	}
+
+	var after *entity.ActivityCursor
+	if cursor := query.Get("cursor"); cursor != "" {
+		after, err = parseActivityCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	limit := defaultActivityPageSize
+	if value := query.Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > maxActivityPageSize {
+			return nil, ErrInvalidPageSize
+		}
+	}
+
+	detail, err := ParseTrackDetail(query.Get("track"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.ActivityFilter{
+		Types:              activityTypes,
+		From:               from,
+		To:                 to,
+		After:              after,
+		Limit:              limit,
+		WithoutCoordinates: detail == TrackDetailNone,
+	}, nil
+}
+
+type ActivityVisibility string
+
+const (
+	ActivityVisibilityPublic  ActivityVisibility = "public"
+	ActivityVisibilityFriends ActivityVisibility = "friends"
+	ActivityVisibilityPrivate ActivityVisibility = "private"
+)
+
+var (
+	ErrInvalidActivityVisibility = errors.New("invalid activity visibility")
+)
+
+func NewActivityVisibilityFromEntity(v entity.ActivityVisibility) ActivityVisibility {
+	switch v {
+	case entity.ActivityVisibilityFriends:
+		return ActivityVisibilityFriends
+	case entity.ActivityVisibilityPrivate:
+		return ActivityVisibilityPrivate
+	default:
+		return ActivityVisibilityPublic
+	}
+}
+
+// ToEntity treats an empty visibility as public.
+func (v ActivityVisibility) ToEntity() (entity.ActivityVisibility, error) {
+	switch v {
+	case "", ActivityVisibilityPublic:
+		return entity.ActivityVisibilityPublic, nil
+	case ActivityVisibilityFriends:
+		return entity.ActivityVisibilityFriends, nil
+	case ActivityVisibilityPrivate:
+		return entity.ActivityVisibilityPrivate, nil
+	default:
+		return 0, ErrInvalidActivityVisibility
+	}
+}
+
+// TrackDetail chooses how activity tracks are served: simplified into an
+// encoded polyline, the default, as every stored coordinate, or not at all.
+type TrackDetail string
+
+const (
+	TrackDetailSimplified TrackDetail = "simplified"
+	TrackDetailFull       TrackDetail = "full"
+	TrackDetailNone       TrackDetail = "none"
+)
+
+// simplifyTolerance is how far, in meters, a simplified track may stray from
+// the stored one.
+const simplifyTolerance = 5
+
+var (
+	ErrInvalidTrackDetail = errors.New("invalid track detail")
+)
+
+func ParseTrackDetail(detail string) (TrackDetail, error) {
+	switch TrackDetail(detail) {
+	case "", TrackDetailSimplified:
+		return TrackDetailSimplified, nil
+	case TrackDetailFull:
+		return TrackDetailFull, nil
+	case TrackDetailNone:
+		return TrackDetailNone, nil
+	default:
+		return "", ErrInvalidTrackDetail
+	}
+}
+
 type Activity struct {
-	ID          string        `json:"id"`
-	UserID      string        `json:"user_id"`
-	Title       string        `json:"title"`
-	Date        time.Time     `json:"date"`
-	Duration    int           `json:"duration"`
-	Distance    int           `json:"distance"`
-	Coordinates []*Coordinate `json:"coordinates"`
-	User        *User         `json:"user"`
+	ID            string               `json:"id"`
+	UserID        string               `json:"user_id"`
+	Title         string               `json:"title"`
+	Type          ActivityType         `json:"type"`
+	Date          time.Time            `json:"date"`
+	Duration      int                  `json:"duration"`
+	Distance      int                  `json:"distance"`
+	Pace          float64              `json:"pace"`
+	ElevationGain int                  `json:"elevation_gain"`
+	ReviewStatus  ActivityReviewStatus `json:"review_status"`
+	ReviewReasons []string             `json:"review_reasons,omitempty"`
+	Visibility    ActivityVisibility   `json:"visibility"`
+	Polyline      string               `json:"polyline,omitempty"`
+	Coordinates   []*Coordinate        `json:"coordinates,omitempty"`
+	KudosCount    int                  `json:"kudos_count"`
+	CommentCount  int                  `json:"comment_count"`
+	Media         []*Media             `json:"media"`
+	Records       []*PersonalRecord    `json:"records,omitempty"`
+	User          *User                `json:"user"`
 }
 
-func NewActivityFromEntity(activity *entity.Activity) *Activity {
+func NewActivityFromEntity(activity *entity.Activity, detail TrackDetail) *Activity {
+	var polyline string
+	var coordinates []*Coordinate
+	switch {
+	case detail == TrackDetailFull:
+		coordinates = newCoordinatesFromEntity(activity.Coordinates)
+	case detail == TrackDetailSimplified && len(activity.Coordinates) > 0:
+		polyline = geo.EncodePolyline(geo.Simplify(activity.Coordinates, simplifyTolerance))
+	}
+
 	return &Activity{
-		ID:          activity.ID.String(),
-		UserID:      activity.UserID.String(),
-		Title:       activity.Title,
-		Date:        activity.Date,
-		Duration:    activity.Duration,
-		Distance:    activity.Distance,
-		Coordinates: newCoordinatesFromEntity(activity.Coordinates),
-		User:        NewUserFromEntity(activity.User),
+		ID:            activity.ID.String(),
+		UserID:        activity.UserID.String(),
+		Title:         activity.Title,
+		Type:          NewActivityTypeFromEntity(activity.Type),
+		Date:          activity.Date,
+		Duration:      activity.Duration,
+		Distance:      activity.Distance,
+		Pace:          geo.Pace(float64(activity.Distance), activity.Duration),
+		ElevationGain: activity.ElevationGain,
+		ReviewStatus:  NewActivityReviewStatusFromEntity(activity.ReviewStatus),
+		ReviewReasons: activity.ReviewReasons,
+		Visibility:    NewActivityVisibilityFromEntity(activity.Visibility),
+		Polyline:      polyline,
+		Coordinates:   coordinates,
+		KudosCount:    activity.KudosCount,
+		CommentCount:  activity.CommentCount,
+		Media:         newMediaFromEntity(activity.Media),
+		Records:       NewPersonalRecordsFromEntity(activity.BestEfforts),
+		User:          NewUserFromEntity(activity.User),
+	}
+}
+
+type ActivityPage struct {
+	Activities []*Activity `json:"activities"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+	LevelUps   []*LevelUp  `json:"level_ups,omitempty"`
+}
+
+func NewActivityPageFromEntity(page *entity.ActivityPage, detail TrackDetail) *ActivityPage {
+	activities := make([]*Activity, 0, len(page.Activities))
+	for _, activity := range page.Activities {
+		activities = append(activities, NewActivityFromEntity(activity, detail))
+	}
+
+	var levelUps []*LevelUp
+	for _, levelUp := range page.LevelUps {
+		levelUps = append(levelUps, NewLevelUpFromEntity(levelUp))
+	}
+
+	return &ActivityPage{
+		Activities: activities,
+		NextCursor: newActivityCursorFromEntity(page.Next),
+		LevelUps:   levelUps,
 	}
 }
 
@@ -61,14 +411,22 @@ func (a *Activity) ToEntity() (*entity.Activity, error) {
 }
 
 type Coordinate struct {
-	Lat  float64 `json:"lat"`
-	Long float64 `json:"long"`
+	Lat       float64    `json:"lat"`
+	Long      float64    `json:"long"`
+	Time      *time.Time `json:"time,omitempty"`
+	Altitude  *float64   `json:"altitude,omitempty"`
+	HeartRate *int       `json:"heart_rate,omitempty"`
+	Cadence   *int       `json:"cadence,omitempty"`
 }
 
 func newCoordinateFromEntity(coordinate *entity.Coordinate) *Coordinate {
 	return &Coordinate{
-		Lat:  coordinate.Lat,
-		Long: coordinate.Long,
+		Lat:       coordinate.Lat,
+		Long:      coordinate.Long,
+		Time:      coordinate.Time,
+		Altitude:  coordinate.Altitude,
+		HeartRate: coordinate.HeartRate,
+		Cadence:   coordinate.Cadence,
 	}
 }
 
@@ -82,38 +440,56 @@ func newCoordinatesFromEntity(coordinates []*entity.Coordinate) []*Coordinate {
 
 func (c *Coordinate) ToEntity(order int) *entity.Coordinate {
 	return &entity.Coordinate{
-		Lat:   c.Lat,
-		Long:  c.Long,
-		Order: order,
+		Lat:       c.Lat,
+		Long:      c.Long,
+		Order:     order,
+		Time:      c.Time,
+		Altitude:  c.Altitude,
+		HeartRate: c.HeartRate,
+		Cadence:   c.Cadence,
 	}
 }
 
 type CreateActivityCoordinateInput struct {
-	Lat  float64 `json:"lat"`
-	Long float64 `json:"long"`
+	Lat       float64    `json:"lat"`
+	Long      float64    `json:"long"`
+	Time      *time.Time `json:"time,omitempty"`
+	Altitude  *float64   `json:"altitude,omitempty"`
+	HeartRate *int       `json:"heart_rate,omitempty"`
+	Cadence   *int       `json:"cadence,omitempty"`
 }
 
 func (c *CreateActivityCoordinateInput) ToEntity(order int) *entity.Coordinate {
 	return &entity.Coordinate{
-		Lat:   c.Lat,
-		Long:  c.Long,
-		Order: order,
+		Lat:       c.Lat,
+		Long:      c.Long,
+		Order:     order,
+		Time:      c.Time,
+		Altitude:  c.Altitude,
+		HeartRate: c.HeartRate,
+		Cadence:   c.Cadence,
 	}
 }
 
 type CreateActivityInput struct {
-	UserID      string                           `json:"user_id"`
 	Title       string                           `json:"title"`
+	Type        ActivityType                     `json:"type"`
 	Date        time.Time                        `json:"date"`
 	Duration    int                              `json:"duration"`
 	Distance    int                              `json:"distance"`
+	Visibility  ActivityVisibility               `json:"visibility"`
 	Coordinates []*CreateActivityCoordinateInput `json:"coordinates"`
 }
 
-func (c *CreateActivityInput) ToEntity() (*entity.Activity, error) {
-	userID, err := uuid.Parse(c.UserID)
+func (c *CreateActivityInput) ToEntity(userID uuid.UUID) (*entity.Activity, error) {
+	activityType, err := c.Type.ToEntity()
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse user id: %v", err)
+		return nil, err
+	}
+
+	visibility, err := c.Visibility.ToEntity()
+	if err != nil {
+		return nil, err
 	}
 
 	coordinates := make([]*entity.Coordinate, 0, len(c.Coordinates))
@@ -124,9 +500,147 @@ func (c *CreateActivityInput) ToEntity() (*entity.Activity, error) {
 	return &entity.Activity{
 		UserID:      userID,
 		Title:       c.Title,
+		Type:        activityType,
 		Date:        c.Date,
 		Duration:    c.Duration,
 		Distance:    c.Distance,
+		Visibility:  visibility,
+		Coordinates: coordinates,
+	}, nil
+}
+
+type UpdateActivityInput struct {
+	Title       string                           `json:"title"`
+	Type        ActivityType                     `json:"type"`
+	Date        time.Time                        `json:"date"`
+	Duration    int                              `json:"duration"`
+	Distance    int                              `json:"distance"`
+	Visibility  ActivityVisibility               `json:"visibility"`
+	Coordinates []*CreateActivityCoordinateInput `json:"coordinates"`
+}
+
+// ToEntity leaves the coordinates nil when none are given so the stored track
+// is kept. The visibility, type and date are required so an omitted one never
+// makes a private activity public, another type of activity a run or moves it
+// out of every period.
+func (u *UpdateActivityInput) ToEntity(id string) (*entity.Activity, error) {
+	activityID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse activity id: %v", err)
+	}
+
+	if u.Date.IsZero() {
+		return nil, ErrDateRequired
+	}
+
+	if u.Visibility == "" {
+		return nil, ErrInvalidActivityVisibility
+	}
+
+	visibility, err := u.Visibility.ToEntity()
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Type == "" {
+		return nil, ErrInvalidActivityType
+	}
+
+	activityType, err := u.Type.ToEntity()
+	if err != nil {
+		return nil, err
+	}
+
+	var coordinates []*entity.Coordinate
+	if len(u.Coordinates) > 0 {
+		coordinates = make([]*entity.Coordinate, 0, len(u.Coordinates))
+		for i, coordinate := range u.Coordinates {
+			coordinates = append(coordinates, coordinate.ToEntity(i))
+		}
+	}
+
+	return &entity.Activity{
+		ID:          activityID,
+		Title:       u.Title,
+		Type:        activityType,
+		Date:        u.Date,
+		Duration:    u.Duration,
+		Distance:    u.Distance,
+		Visibility:  visibility,
 		Coordinates: coordinates,
 	}, nil
 }
+
+type SplitUnit string
+
+const (
+	SplitUnitMetric   SplitUnit = "metric"
+	SplitUnitImperial SplitUnit = "imperial"
+)
+
+var (
+	ErrInvalidSplitUnit = errors.New("invalid split unit")
+)
+
+func ParseSplitUnit(unit string) (SplitUnit, error) {
+	switch SplitUnit(unit) {
+	case "", SplitUnitMetric:
+		return SplitUnitMetric, nil
+	case SplitUnitImperial:
+		return SplitUnitImperial, nil
+	default:
+		return "", ErrInvalidSplitUnit
+	}
+}
+
+func (s SplitUnit) Meters() float64 {
+	if s == SplitUnitImperial {
+		return geo.Mile
+	}
+
+	return geo.Kilometer
+}
+
+type Split struct {
+	Number         int      `json:"number"`
+	Distance       float64  `json:"distance"`
+	Duration       float64  `json:"duration"`
+	Pace           float64  `json:"pace"`
+	ElevationDelta *float64 `json:"elevation_delta,omitempty"`
+}
+
+func newSplitFromEntity(split *geo.Split) *Split {
+	return &Split{
+		Number:         split.Number,
+		Distance:       split.Distance,
+		Duration:       split.Duration,
+		Pace:           split.Pace,
+		ElevationDelta: split.ElevationDelta,
+	}
+}
+
+type Splits struct {
+	Unit          SplitUnit `json:"unit"`
+	Splits        []*Split  `json:"splits"`
+	FastestSplit  int       `json:"fastest_split"`
+	NegativeSplit bool      `json:"negative_split"`
+}
+
+func NewSplitsFromEntity(splits *geo.Splits, unit SplitUnit) *Splits {
+	result := make([]*Split, 0, len(splits.Splits))
+	for _, split := range splits.Splits {
+		result = append(result, newSplitFromEntity(split))
+	}
+
+	var fastest int
+	if splits.Fastest != nil {
+		fastest = splits.Fastest.Number
+	}
+
+	return &Splits{
+		Unit:          unit,
+		Splits:        result,
+		FastestSplit:  fastest,
+		NegativeSplit: splits.NegativeSplit,
+	}
+}
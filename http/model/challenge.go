@@ -56,6 +56,7 @@ type Challenge struct {
 	EndDate       *time.Time          `json:"end_date,omitempty"`
 	TotalDistance *int                `json:"total_distance,omitempty"`
 	Type          ChallengeType       `json:"type"`
+	ActivityTypes []ActivityType      `json:"activity_types"`
 	Finished      bool                `json:"finished"`
 	Ranking       []*ChallengeRanking `json:"ranking,omitempty"`
 }
@@ -76,6 +77,7 @@ func NewChallengeFromEntity(c *entity.Challenge, ranking []*entity.ChallengeRank
 		EndDate:       c.EndDate,
 		TotalDistance: c.TotalDistance,
 		Type:          NewChallengeTypeFromEntity(c.Type),
+		ActivityTypes: newActivityTypesFromEntity(c.ActivityTypes),
 		Finished:      finished,
 		Ranking:       NewChallengeRankingFromEntity(ranking),
 	}
@@ -107,7 +109,9 @@ type CreateChallengeInput struct {
 	EndDate       *time.Time    `json:"end_date,omitempty"`
 	TotalDistance *int          `json:"total_distance,omitempty"`
 	Type          ChallengeType `json:"type"`
-	UserID        string        `json:"created_by"`
+	// ActivityTypes restricts the activities that count toward the challenge.
+	// Empty accepts all of them.
+	ActivityTypes []ActivityType `json:"activity_types,omitempty"`
 }
 
 func (c *CreateChallengeInput) Validate() error {
@@ -146,10 +150,10 @@ func (c *CreateChallengeInput) Validate() error {
 	return nil
 }
 
-func (c *CreateChallengeInput) ToEntity() (*entity.Challenge, error) {
-	userID, err := uuid.Parse(c.UserID)
+func (c *CreateChallengeInput) ToEntity(userID uuid.UUID) (*entity.Challenge, error) {
+	activityTypes, err := activityTypesToEntity(c.ActivityTypes)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse user id: %v", err)
+		return nil, err
 	}
 
 	return &entity.Challenge{
@@ -159,11 +163,29 @@ func (c *CreateChallengeInput) ToEntity() (*entity.Challenge, error) {
 		EndDate:       c.EndDate,
 		TotalDistance: c.TotalDistance,
 		Type:          c.Type.ToEntity(),
+		ActivityTypes: activityTypes,
 		CreatedBy:     userID,
 	}, nil
 }
 
+type UpdateChallengeInput struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+func (u *UpdateChallengeInput) ToEntity(id string) (*entity.Challenge, error) {
+	challengeID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse challenge id: %v", err)
+	}
+
+	return &entity.Challenge{
+		ID:          challengeID,
+		Title:       u.Title,
+		Description: u.Description,
+	}, nil
+}
+
 type JoinChallengeInput struct {
-	UserID      string `json:"user_id"`
 	ChallengeID string `json:"challenge_id"`
 }
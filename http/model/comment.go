@@ -0,0 +1,84 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"runmate_api/internal/entity"
+)
+
+type Comment struct {
+	ID        string     `json:"id"`
+	UserID    string     `json:"user_id"`
+	ParentID  *string    `json:"parent_id,omitempty"`
+	Content   string     `json:"content"`
+	CreatedAt time.Time  `json:"created_at"`
+	User      *User      `json:"user"`
+	Replies   []*Comment `json:"replies"`
+}
+
+func NewCommentFromEntity(comment *entity.Comment) *Comment {
+	var parentID *string
+	if comment.ParentID != nil {
+		id := comment.ParentID.String()
+		parentID = &id
+	}
+
+	return &Comment{
+		ID:        comment.ID.String(),
+		UserID:    comment.UserID.String(),
+		ParentID:  parentID,
+		Content:   comment.Content,
+		CreatedAt: comment.CreatedAt,
+		User:      NewUserFromEntity(comment.User),
+		Replies:   []*Comment{},
+	}
+}
+
+// NewCommentThreadsFromEntity nests the replies under their parent comments,
+// keeping the order of comments.
+func NewCommentThreadsFromEntity(comments []*entity.Comment) []*Comment {
+	byID := make(map[string]*Comment, len(comments))
+	for _, comment := range comments {
+		byID[comment.ID.String()] = NewCommentFromEntity(comment)
+	}
+
+	threads := make([]*Comment, 0, len(comments))
+	for _, comment := range comments {
+		c := byID[comment.ID.String()]
+		if c.ParentID != nil {
+			if parent, ok := byID[*c.ParentID]; ok {
+				parent.Replies = append(parent.Replies, c)
+				continue
+			}
+		}
+
+		threads = append(threads, c)
+	}
+
+	return threads
+}
+
+type CreateCommentInput struct {
+	Content  string  `json:"content"`
+	ParentID *string `json:"parent_id"`
+}
+
+func (c *CreateCommentInput) ToEntity() (*entity.Comment, error) {
+	var parentID *uuid.UUID
+	if c.ParentID != nil {
+		id, err := uuid.Parse(*c.ParentID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse parent id: %v", err)
+		}
+
+		parentID = &id
+	}
+
+	return &entity.Comment{
+		ParentID: parentID,
+		Content:  c.Content,
+	}, nil
+}
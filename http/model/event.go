@@ -2,7 +2,6 @@ package model
 
 import (
 	"errors"
-	"fmt"
 	"runmate_api/internal/entity"
 	"time"
 
@@ -38,9 +37,8 @@ func NewEventFromEntity(c *entity.Event) *Event {
 }
 
 type CreateEventInput struct {
-	Title  string    `json:"title"`
-	Date   time.Time `json:"date"`
-	UserID string    `json:"created_by"`
+	Title string    `json:"title"`
+	Date  time.Time `json:"date"`
 }
 
 func (c *CreateEventInput) Validate() error {
@@ -55,12 +53,7 @@ func (c *CreateEventInput) Validate() error {
 	return nil
 }
 
-func (c *CreateEventInput) ToEntity() (*entity.Event, error) {
-	userID, err := uuid.Parse(c.UserID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse user id: %v", err)
-	}
-
+func (c *CreateEventInput) ToEntity(userID uuid.UUID) (*entity.Event, error) {
 	return &entity.Event{
 		Title:     c.Title,
 		Date:      c.Date,
@@ -69,6 +62,5 @@ func (c *CreateEventInput) ToEntity() (*entity.Event, error) {
 }
 
 type JoinQuitEventInput struct {
-	UserID  string `json:"user_id"`
 	EventID string `json:"event_id"`
 }
@@ -0,0 +1,186 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"runmate_api/internal/entity"
+)
+
+type GoalPeriod string
+
+const (
+	GoalPeriodDay   GoalPeriod = "day"
+	GoalPeriodWeek  GoalPeriod = "week"
+	GoalPeriodMonth GoalPeriod = "month"
+)
+
+func NewGoalPeriodFromEntity(p entity.GoalPeriod) GoalPeriod {
+	switch p {
+	case entity.GoalPeriodDay:
+		return GoalPeriodDay
+	case entity.GoalPeriodMonth:
+		return GoalPeriodMonth
+	default:
+		return GoalPeriodWeek
+	}
+}
+
+func (p GoalPeriod) ToEntity() (entity.GoalPeriod, error) {
+	switch p {
+	case GoalPeriodDay:
+		return entity.GoalPeriodDay, nil
+	case GoalPeriodWeek:
+		return entity.GoalPeriodWeek, nil
+	case GoalPeriodMonth:
+		return entity.GoalPeriodMonth, nil
+	default:
+		return 0, entity.ErrInvalidGoal
+	}
+}
+
+type GoalMetric string
+
+const (
+	GoalMetricDistance   GoalMetric = "distance"
+	GoalMetricDuration   GoalMetric = "duration"
+	GoalMetricActivities GoalMetric = "activities"
+	GoalMetricActiveDays GoalMetric = "active_days"
+)
+
+func NewGoalMetricFromEntity(m entity.GoalMetric) GoalMetric {
+	switch m {
+	case entity.GoalMetricDuration:
+		return GoalMetricDuration
+	case entity.GoalMetricActivities:
+		return GoalMetricActivities
+	case entity.GoalMetricActiveDays:
+		return GoalMetricActiveDays
+	default:
+		return GoalMetricDistance
+	}
+}
+
+func (m GoalMetric) ToEntity() (entity.GoalMetric, error) {
+	switch m {
+	case GoalMetricDistance:
+		return entity.GoalMetricDistance, nil
+	case GoalMetricDuration:
+		return entity.GoalMetricDuration, nil
+	case GoalMetricActivities:
+		return entity.GoalMetricActivities, nil
+	case GoalMetricActiveDays:
+		return entity.GoalMetricActiveDays, nil
+	default:
+		return 0, entity.ErrInvalidGoal
+	}
+}
+
+type GoalPeriodProgress struct {
+	Start string `json:"start"`
+	Value int    `json:"value"`
+	Met   bool   `json:"met"`
+}
+
+func newGoalPeriodProgressFromEntity(period *entity.GoalPeriodProgress) *GoalPeriodProgress {
+	return &GoalPeriodProgress{
+		Start: period.Start.Format("2006-01-02"),
+		Value: period.Value,
+		Met:   period.Met,
+	}
+}
+
+type Goal struct {
+	ID            string                `json:"id"`
+	Period        GoalPeriod            `json:"period"`
+	Metric        GoalMetric            `json:"metric"`
+	Target        int                   `json:"target"`
+	DailyDistance *int                  `json:"daily_distance,omitempty"`
+	ActivityTypes []ActivityType        `json:"activity_types"`
+	CreatedAt     time.Time             `json:"created_at"`
+	EndedAt       *time.Time            `json:"ended_at,omitempty"`
+	CurrentPeriod *GoalPeriodProgress   `json:"current_period,omitempty"`
+	Periods       []*GoalPeriodProgress `json:"periods,omitempty"`
+	CurrentStreak int                   `json:"current_streak"`
+	LongestStreak int                   `json:"longest_streak"`
+}
+
+func NewGoalFromEntity(goal *entity.Goal) *Goal {
+	result := &Goal{
+		ID:            goal.ID.String(),
+		Period:        NewGoalPeriodFromEntity(goal.Period),
+		Metric:        NewGoalMetricFromEntity(goal.Metric),
+		Target:        goal.Target,
+		DailyDistance: goal.DailyDistance,
+		ActivityTypes: newActivityTypesFromEntity(goal.ActivityTypes),
+		CreatedAt:     goal.CreatedAt,
+		EndedAt:       goal.EndedAt,
+	}
+
+	if goal.Progress != nil {
+		result.CurrentPeriod = newGoalPeriodProgressFromEntity(goal.Progress.CurrentPeriod())
+		result.CurrentStreak = goal.Progress.CurrentStreak
+		result.LongestStreak = goal.Progress.LongestStreak
+		for _, period := range goal.Progress.Periods {
+			result.Periods = append(result.Periods, newGoalPeriodProgressFromEntity(period))
+		}
+	}
+
+	return result
+}
+
+func NewGoalsFromEntity(goals []*entity.Goal) []*Goal {
+	result := make([]*Goal, 0, len(goals))
+	for _, goal := range goals {
+		result = append(result, NewGoalFromEntity(goal))
+	}
+
+	return result
+}
+
+type CreateGoalInput struct {
+	Period GoalPeriod `json:"period"`
+	Metric GoalMetric `json:"metric"`
+	// Target is in meters for distance goals and in seconds for duration
+	// goals.
+	Target int `json:"target"`
+	// DailyDistance is the distance a day must sum to count toward active
+	// days goals.
+	DailyDistance *int `json:"daily_distance,omitempty"`
+	// ActivityTypes restricts the activities that count toward the goal.
+	// Empty accepts all of them.
+	ActivityTypes []ActivityType `json:"activity_types,omitempty"`
+}
+
+func (c *CreateGoalInput) ToEntity(userID string) (*entity.Goal, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse user id: %v", err)
+	}
+
+	period, err := c.Period.ToEntity()
+	if err != nil {
+		return nil, err
+	}
+
+	metric, err := c.Metric.ToEntity()
+	if err != nil {
+		return nil, err
+	}
+
+	activityTypes, err := activityTypesToEntity(c.ActivityTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.Goal{
+		UserID:        id,
+		Period:        period,
+		Metric:        metric,
+		Target:        c.Target,
+		DailyDistance: c.DailyDistance,
+		ActivityTypes: activityTypes,
+	}, nil
+}
@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+
+	"runmate_api/internal/entity"
+)
+
+type Kudos struct {
+	UserID    string    `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+	User      *User     `json:"user"`
+}
+
+func NewKudosFromEntity(kudos *entity.Kudos) *Kudos {
+	return &Kudos{
+		UserID:    kudos.UserID.String(),
+		CreatedAt: kudos.CreatedAt,
+		User:      NewUserFromEntity(kudos.User),
+	}
+}
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+
+	"runmate_api/internal/entity"
+)
+
+type LevelUp struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Level     int       `json:"level"`
+	Date      time.Time `json:"date"`
+	CreatedAt time.Time `json:"created_at"`
+	User      *User     `json:"user"`
+}
+
+func NewLevelUpFromEntity(levelUp *entity.LevelUp) *LevelUp {
+	return &LevelUp{
+		ID:        levelUp.ID.String(),
+		UserID:    levelUp.UserID.String(),
+		Level:     levelUp.Level,
+		Date:      levelUp.Date,
+		CreatedAt: levelUp.CreatedAt,
+		User:      NewUserFromEntity(levelUp.User),
+	}
+}
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"time"
+
+	"runmate_api/internal/entity"
+)
+
+type Media struct {
+	ID           string    `json:"id"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+	ContentType  string    `json:"content_type"`
+	Width        int       `json:"width"`
+	Height       int       `json:"height"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func NewMediaFromEntity(media *entity.ActivityMedia) *Media {
+	return &Media{
+		ID:           media.ID.String(),
+		URL:          media.URL,
+		ThumbnailURL: media.ThumbnailURL,
+		ContentType:  media.ContentType,
+		Width:        media.Width,
+		Height:       media.Height,
+		CreatedAt:    media.CreatedAt,
+	}
+}
+
+func newMediaFromEntity(medias []*entity.ActivityMedia) []*Media {
+	result := make([]*Media, 0, len(medias))
+	for _, media := range medias {
+		result = append(result, NewMediaFromEntity(media))
+	}
+
+	return result
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"runmate_api/internal/entity"
+)
+
+type PrivacyZone struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Lat       float64   `json:"lat"`
+	Long      float64   `json:"long"`
+	Radius    int       `json:"radius"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func NewPrivacyZoneFromEntity(zone *entity.PrivacyZone) *PrivacyZone {
+	return &PrivacyZone{
+		ID:        zone.ID.String(),
+		Name:      zone.Name,
+		Lat:       zone.Lat,
+		Long:      zone.Long,
+		Radius:    zone.Radius,
+		CreatedAt: zone.CreatedAt,
+	}
+}
+
+type CreatePrivacyZoneInput struct {
+	Name   string  `json:"name"`
+	Lat    float64 `json:"lat"`
+	Long   float64 `json:"long"`
+	Radius int     `json:"radius"`
+}
+
+func (c *CreatePrivacyZoneInput) ToEntity(userID string) (*entity.PrivacyZone, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse user id: %v", err)
+	}
+
+	return &entity.PrivacyZone{
+		UserID: id,
+		Name:   c.Name,
+		Lat:    c.Lat,
+		Long:   c.Long,
+		Radius: c.Radius,
+	}, nil
+}
@@ -0,0 +1,72 @@
+package model
+
+import (
+	"time"
+
+	"runmate_api/internal/entity"
+	"runmate_api/internal/geo"
+)
+
+type RecordKind string
+
+const (
+	RecordKind1K           RecordKind = "1k"
+	RecordKind5K           RecordKind = "5k"
+	RecordKind10K          RecordKind = "10k"
+	RecordKindHalfMarathon RecordKind = "half_marathon"
+	RecordKindMarathon     RecordKind = "marathon"
+	RecordKindLongestRun   RecordKind = "longest_run"
+)
+
+func NewRecordKindFromEntity(kind entity.RecordKind) RecordKind {
+	switch kind {
+	case entity.RecordKind5K:
+		return RecordKind5K
+	case entity.RecordKind10K:
+		return RecordKind10K
+	case entity.RecordKindHalfMarathon:
+		return RecordKindHalfMarathon
+	case entity.RecordKindMarathon:
+		return RecordKindMarathon
+	case entity.RecordKindLongestRun:
+		return RecordKindLongestRun
+	default:
+		return RecordKind1K
+	}
+}
+
+type PersonalRecord struct {
+	Kind          RecordKind `json:"kind"`
+	Distance      int        `json:"distance"`
+	Duration      int        `json:"duration"`
+	Pace          float64    `json:"pace"`
+	Date          time.Time  `json:"date"`
+	ActivityID    string     `json:"activity_id"`
+	ActivityTitle string     `json:"activity_title,omitempty"`
+}
+
+func NewPersonalRecordFromEntity(record *entity.BestEffort) *PersonalRecord {
+	var activityTitle string
+	if record.Activity != nil {
+		activityTitle = record.Activity.Title
+	}
+
+	return &PersonalRecord{
+		Kind:          NewRecordKindFromEntity(record.Kind),
+		Distance:      record.Distance,
+		Duration:      record.Duration,
+		Pace:          geo.Pace(float64(record.Distance), record.Duration),
+		Date:          record.Date,
+		ActivityID:    record.ActivityID.String(),
+		ActivityTitle: activityTitle,
+	}
+}
+
+func NewPersonalRecordsFromEntity(records []*entity.BestEffort) []*PersonalRecord {
+	result := make([]*PersonalRecord, 0, len(records))
+	for _, record := range records {
+		result = append(result, NewPersonalRecordFromEntity(record))
+	}
+
+	return result
+}
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"runmate_api/internal/entity"
+	"runmate_api/internal/geo"
+)
+
+type ActivityTotals struct {
+	Start         string  `json:"start,omitempty"`
+	Count         int     `json:"count"`
+	Distance      int     `json:"distance"`
+	Duration      int     `json:"duration"`
+	Pace          float64 `json:"pace"`
+	ElevationGain int     `json:"elevation_gain"`
+}
+
+func NewActivityTotalsFromEntity(totals *entity.ActivityTotals) *ActivityTotals {
+	var start string
+	if !totals.Start.IsZero() {
+		start = totals.Start.Format("2006-01-02")
+	}
+
+	return &ActivityTotals{
+		Start:         start,
+		Count:         totals.Count,
+		Distance:      totals.Distance,
+		Duration:      totals.Duration,
+		Pace:          geo.Pace(float64(totals.Distance), totals.Duration),
+		ElevationGain: totals.ElevationGain,
+	}
+}
+
+func newActivityTotalsFromEntity(totals []*entity.ActivityTotals) []*ActivityTotals {
+	result := make([]*ActivityTotals, 0, len(totals))
+	for _, t := range totals {
+		result = append(result, NewActivityTotalsFromEntity(t))
+	}
+
+	return result
+}
+
+type UserStats struct {
+	TimeZone  string            `json:"time_zone"`
+	WeekStart WeekStart         `json:"week_start"`
+	Weeks     []*ActivityTotals `json:"weeks"`
+	Months    []*ActivityTotals `json:"months"`
+	Years     []*ActivityTotals `json:"years"`
+	AllTime   *ActivityTotals   `json:"all_time"`
+}
+
+func NewUserStatsFromEntity(stats *entity.UserStats) *UserStats {
+	return &UserStats{
+		TimeZone:  stats.TimeZone,
+		WeekStart: NewWeekStartFromEntity(stats.WeekStart),
+		Weeks:     newActivityTotalsFromEntity(stats.Weeks),
+		Months:    newActivityTotalsFromEntity(stats.Months),
+		Years:     newActivityTotalsFromEntity(stats.Years),
+		AllTime:   NewActivityTotalsFromEntity(stats.AllTime),
+	}
+}
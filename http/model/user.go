@@ -1,63 +1,120 @@
 package model
 
 import (
+	"fmt"
 	"time"
 
+	"github.com/google/uuid"
+
+	"runmate_api/internal/auth"
 	"runmate_api/internal/entity"
 )
 
-type GoalDayActivity struct {
+type WeekStart string
+
+const (
+	WeekStartSunday WeekStart = "sunday"
+	WeekStartMonday WeekStart = "monday"
+)
+
+func NewWeekStartFromEntity(weekday time.Weekday) WeekStart {
+	if weekday == time.Monday {
+		return WeekStartMonday
+	}
+
+	return WeekStartSunday
+}
+
+// ToEntity defaults to weeks starting on Sunday.
+func (w WeekStart) ToEntity() (time.Weekday, error) {
+	switch w {
+	case WeekStartSunday, "":
+		return time.Sunday, nil
+	case WeekStartMonday:
+		return time.Monday, nil
+	default:
+		return 0, entity.ErrInvalidWeekStart
+	}
+}
+
+type DayActivity struct {
 	Date     string `json:"date"`
 	Distance int    `json:"distance"`
 }
 
-func newGoalDayActivityFromEntity(activity *entity.UserDayActitivy) *GoalDayActivity {
-	return &GoalDayActivity{
+func newDayActivityFromEntity(activity *entity.UserDayActitivy) *DayActivity {
+	return &DayActivity{
 		Date:     activity.Date.Format("2006-01-02"),
 		Distance: activity.Distance,
 	}
 }
 
-type Goal struct {
-	Days           *int               `json:"days,omitempty"`
-	DailyDistance  *int               `json:"daily_distance,omitempty"`
-	WeekActivities []*GoalDayActivity `json:"week_activities,omitempty"`
+type Streak struct {
+	Current int `json:"current"`
+	Longest int `json:"longest"`
+}
+
+func newStreakFromEntity(streak *entity.Streak) *Streak {
+	if streak == nil {
+		return nil
+	}
+
+	return &Streak{
+		Current: streak.Current,
+		Longest: streak.Longest,
+	}
 }
 
 type User struct {
-	ID          string    `json:"id"`
-	Username    string    `json:"username"`
-	Name        string    `json:"name"`
-	Email       string    `json:"email"`
-	Birthdate   time.Time `json:"birthdate"`
-	Role        int8      `json:"role"`
-	XP          int       `json:"xp"`
-	Level       int       `json:"level"`
-	NextLevelXP int       `json:"next_level_xp"`
-	Goal        *Goal     `json:"goal,omitempty"`
+	ID             string         `json:"id"`
+	Username       string         `json:"username"`
+	Name           string         `json:"name"`
+	Email          string         `json:"email"`
+	Birthdate      time.Time      `json:"birthdate"`
+	Role           int8           `json:"role"`
+	XP             int            `json:"xp"`
+	Level          int            `json:"level"`
+	NextLevelXP    int            `json:"next_level_xp"`
+	TimeZone       string         `json:"time_zone"`
+	WeekStart      WeekStart      `json:"week_start"`
+	StreakFreezes  int            `json:"streak_freezes"`
+	ProfileFrame   string         `json:"profile_frame,omitempty"`
+	ProfileFrames  []string       `json:"profile_frames,omitempty"`
+	DailyStreak    *Streak        `json:"daily_streak,omitempty"`
+	WeeklyStreak   *Streak        `json:"weekly_streak,omitempty"`
+	WeekActivities []*DayActivity `json:"week_activities,omitempty"`
+	Goals          []*Goal        `json:"goals,omitempty"`
 }
 
 func NewUserFromEntity(user *entity.User) *User {
-	weekActivities := make([]*GoalDayActivity, 0, 7)
+	if user == nil {
+		return nil
+	}
+
+	var weekActivities []*DayActivity
 	for _, activity := range user.WeekActivities {
-		weekActivities = append(weekActivities, newGoalDayActivityFromEntity(activity))
+		weekActivities = append(weekActivities, newDayActivityFromEntity(activity))
 	}
 
 	return &User{
-		ID:          user.ID.String(),
-		Username:    user.Username,
-		Name:        user.Name,
-		Email:       user.Email,
-		Birthdate:   user.Birthdate,
-		Role:        user.Role,
-		XP:          user.XP,
-		Level:       user.CurrentLevel(),
-		NextLevelXP: user.NextLevelXP(),
-		Goal: &Goal{
-			Days:           user.GoalDays,
-			DailyDistance:  user.GoalDailyDistance,
-			WeekActivities: weekActivities,
-		},
+		ID:             user.ID.String(),
+		Username:       user.Username,
+		Name:           user.Name,
+		Email:          user.Email,
+		Birthdate:      user.Birthdate,
+		Role:           user.Role,
+		XP:             user.XP,
+		Level:          user.CurrentLevel(),
+		NextLevelXP:    user.NextLevelXP(),
+		TimeZone:       user.TimeZone,
+		WeekStart:      NewWeekStartFromEntity(user.WeekStart),
+		StreakFreezes:  user.StreakFreezes,
+		ProfileFrame:   user.ProfileFrame,
+		ProfileFrames:  user.ProfileFrames,
+		DailyStreak:    newStreakFromEntity(user.DailyStreak),
+		WeeklyStreak:   newStreakFromEntity(user.WeeklyStreak),
+		WeekActivities: weekActivities,
+		Goals:          NewGoalsFromEntity(user.Goals),
 	}
 }
 
@@ -67,21 +124,75 @@ type CreateUserInput struct {
 	Email     string    `json:"email"`
 	Password  string    `json:"password"`
 	Birthdate time.Time `json:"birthdate"`
+	TimeZone  string    `json:"time_zone"`
+	WeekStart WeekStart `json:"week_start"`
 }
 
-func (c *CreateUserInput) ToEntity() *entity.User {
+func (c *CreateUserInput) ToEntity() (*entity.User, error) {
+	weekStart, err := c.WeekStart.ToEntity()
+	if err != nil {
+		return nil, err
+	}
+
+	timeZone := c.TimeZone
+	if timeZone == "" {
+		timeZone = entity.DefaultTimeZone
+	}
+
 	return &entity.User{
 		Username:  c.Username,
 		Name:      c.Name,
 		Email:     c.Email,
 		Password:  c.Password,
 		Birthdate: c.Birthdate,
+		TimeZone:  timeZone,
+		WeekStart: weekStart,
 		Role:      0,
+	}, nil
+}
+
+// UpdateUserInput keeps the password, time zone and week start of the user
+// when they are omitted.
+type UpdateUserInput struct {
+	Username  string    `json:"username"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Password  string    `json:"password"`
+	Birthdate time.Time `json:"birthdate"`
+	TimeZone  string    `json:"time_zone"`
+	WeekStart WeekStart `json:"week_start"`
+}
+
+// ToEntity leaves the time zone empty when omitted, and returns the week
+// start apart, nil when omitted.
+func (u *UpdateUserInput) ToEntity(id string) (*entity.User, *time.Weekday, error) {
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse user id: %v", err)
+	}
+
+	var weekStart *time.Weekday
+	if u.WeekStart != "" {
+		weekday, err := u.WeekStart.ToEntity()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		weekStart = &weekday
 	}
+
+	return &entity.User{
+		ID:        userID,
+		Username:  u.Username,
+		Name:      u.Name,
+		Email:     u.Email,
+		Password:  u.Password,
+		Birthdate: u.Birthdate,
+		TimeZone:  u.TimeZone,
+	}, weekStart, nil
 }
 
 type FriendInput struct {
-	UserID   string `json:"user_id"`
 	FriendID string `json:"friend_id"`
 }
 
@@ -90,11 +201,38 @@ type LoginInput struct {
 	Password string `json:"password"`
 }
 
+type RefreshTokenInput struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type Tokens struct {
+	AccessToken      string    `json:"access_token"`
+	RefreshToken     string    `json:"refresh_token"`
+	TokenType        string    `json:"token_type"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+func NewTokensFromAuth(tokens *auth.Tokens) *Tokens {
+	return &Tokens{
+		AccessToken:      tokens.AccessToken,
+		RefreshToken:     tokens.RefreshToken,
+		TokenType:        "Bearer",
+		ExpiresAt:        tokens.AccessExpiresAt,
+		RefreshExpiresAt: tokens.RefreshExpiresAt,
+	}
+}
+
+type LoginOutput struct {
+	*Tokens
+	User *User `json:"user"`
+}
+
 type UpdateUserFCMTokenInput struct {
 	Token string `json:"token"`
 }
 
-type UpdateUserGoalInput struct {
-	Days          int `json:"days"`
-	DailyDistance int `json:"distance"`
+type UpdateUserProfileFrameInput struct {
+	// Frame is one of the profile frames of the user, or empty to remove it.
+	Frame string `json:"frame"`
 }
@@ -0,0 +1,78 @@
+package model
+
+import (
+	"time"
+
+	"runmate_api/internal/entity"
+)
+
+type XPSource string
+
+const (
+	XPSourceActivity        XPSource = "activity"
+	XPSourceAdminAdjustment XPSource = "admin_adjustment"
+	XPSourceLevelUp         XPSource = "level_up"
+)
+
+func NewXPSourceFromEntity(s entity.XPSource) XPSource {
+	switch s {
+	case entity.XPSourceAdminAdjustment:
+		return XPSourceAdminAdjustment
+	case entity.XPSourceLevelUp:
+		return XPSourceLevelUp
+	default:
+		return XPSourceActivity
+	}
+}
+
+type XPTransaction struct {
+	ID        string    `json:"id"`
+	Source    XPSource  `json:"source"`
+	SourceID  *string   `json:"source_id,omitempty"`
+	Amount    int       `json:"amount"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func NewXPTransactionFromEntity(transaction *entity.XPTransaction) *XPTransaction {
+	var sourceID *string
+	if transaction.SourceID != nil {
+		id := transaction.SourceID.String()
+		sourceID = &id
+	}
+
+	return &XPTransaction{
+		ID:        transaction.ID.String(),
+		Source:    NewXPSourceFromEntity(transaction.Source),
+		SourceID:  sourceID,
+		Amount:    transaction.Amount,
+		Reason:    transaction.Reason,
+		CreatedAt: transaction.CreatedAt,
+	}
+}
+
+type XPLedger struct {
+	XP           int              `json:"xp"`
+	Level        int              `json:"level"`
+	NextLevelXP  int              `json:"next_level_xp"`
+	Transactions []*XPTransaction `json:"transactions"`
+}
+
+func NewXPLedgerFromEntity(user *entity.User) *XPLedger {
+	transactions := make([]*XPTransaction, 0, len(user.XPTransactions))
+	for _, transaction := range user.XPTransactions {
+		transactions = append(transactions, NewXPTransactionFromEntity(transaction))
+	}
+
+	return &XPLedger{
+		XP:           user.XP,
+		Level:        user.CurrentLevel(),
+		NextLevelXP:  user.NextLevelXP(),
+		Transactions: transactions,
+	}
+}
+
+type AdjustXPInput struct {
+	Amount int    `json:"amount"`
+	Reason string `json:"reason"`
+}
@@ -0,0 +1,200 @@
+package anticheat
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"runmate_api/internal/entity"
+	"runmate_api/internal/geo"
+)
+
+const (
+	ReasonImpossibleSpeed = "impossible_speed"
+	ReasonTeleport        = "teleport"
+	ReasonWorldRecordPace = "world_record_pace"
+	ReasonDuplicateTrack  = "duplicate_track"
+	ReasonMissingDuration = "missing_duration"
+	ReasonUntimedDistance = "untimed_distance"
+)
+
+const (
+	// maxRunningSpeed is a little above the fastest sprint ever recorded, in
+	// meters per second.
+	maxRunningSpeed = 12.5
+
+	// maxRidingSpeed allows for fast descents on a bike, in meters per second.
+	maxRidingSpeed = 25
+
+	// maxOverspeedDistance is how far, in meters, the track may cover above
+	// the maximum speed before it is treated as cheating rather than GPS noise.
+	maxOverspeedDistance = 200
+
+	// teleportDistance is the largest gap, in meters, allowed between two
+	// consecutive coordinates that cannot be explained by their timestamps.
+	teleportDistance = 1000
+
+	// maxUntimedRunningDistance and maxUntimedRidingDistance are how far, in
+	// meters, an activity may go without a timestamped track to back it.
+	maxUntimedRunningDistance = 10000
+	maxUntimedRidingDistance  = 40000
+)
+
+// recordPace is the record pace in seconds per kilometer for activities of at
+// least distance meters.
+type recordPace struct {
+	distance int
+	pace     float64
+}
+
+// limits are the plausibility thresholds of an activity type. Record paces
+// are sorted from the longest distance down.
+type limits struct {
+	maxSpeed           float64
+	maxUntimedDistance int
+	recordPaces        []recordPace
+}
+
+var runningLimits = &limits{
+	maxSpeed:           maxRunningSpeed,
+	maxUntimedDistance: maxUntimedRunningDistance,
+	recordPaces: []recordPace{
+		{distance: 42195, pace: 171},
+		{distance: 21097, pace: 163},
+		{distance: 10000, pace: 157},
+		{distance: 5000, pace: 151},
+		{distance: 1000, pace: 132},
+	},
+}
+
+var ridingLimits = &limits{
+	maxSpeed:           maxRidingSpeed,
+	maxUntimedDistance: maxUntimedRidingDistance,
+	recordPaces: []recordPace{
+		{distance: 40000, pace: 63},
+		{distance: 1000, pace: 52},
+	},
+}
+
+// limitsFor holds walks and hikes to running limits, as nobody walks faster
+// than the fastest runners.
+func limitsFor(activityType entity.ActivityType) *limits {
+	if activityType == entity.ActivityTypeRide {
+		return ridingLimits
+	}
+
+	return runningLimits
+}
+
+// Analyze returns the reasons why the activity looks implausible for its type.
+// Duplicate tracks depend on stored activities and are checked by the caller
+// through Fingerprint.
+func Analyze(activity *entity.Activity) []string {
+	limits := limitsFor(activity.Type)
+
+	var reasons []string
+	if limits.hasImpossibleSpeed(activity.Coordinates) {
+		reasons = append(reasons, ReasonImpossibleSpeed)
+	}
+
+	if limits.hasTeleport(activity.Coordinates) {
+		reasons = append(reasons, ReasonTeleport)
+	}
+
+	if activity.Distance > 0 && activity.Duration <= 0 {
+		reasons = append(reasons, ReasonMissingDuration)
+	} else if limits.beatsWorldRecord(activity.Distance, activity.Duration) {
+		reasons = append(reasons, ReasonWorldRecordPace)
+	}
+
+	if activity.Distance > limits.maxUntimedDistance && !isTimed(activity.Coordinates) {
+		reasons = append(reasons, ReasonUntimedDistance)
+	}
+
+	return reasons
+}
+
+// isTimed reports whether at least two coordinates are timestamped, which the
+// speed checks need to judge the track.
+func isTimed(coordinates []*entity.Coordinate) bool {
+	var timed int
+	for _, coordinate := range coordinates {
+		if coordinate.Time != nil {
+			timed++
+		}
+	}
+
+	return timed >= 2
+}
+
+func speed(from, to *entity.Coordinate) (float64, bool) {
+	if from.Time == nil || to.Time == nil {
+		return 0, false
+	}
+
+	seconds := to.Time.Sub(*from.Time).Seconds()
+	if seconds <= 0 {
+		return 0, false
+	}
+
+	return geo.CoordinateDistance(from, to) / seconds, true
+}
+
+func (l *limits) hasImpossibleSpeed(coordinates []*entity.Coordinate) bool {
+	var overspeedDistance float64
+	for i := 1; i < len(coordinates); i++ {
+		distance := geo.CoordinateDistance(coordinates[i-1], coordinates[i])
+		if distance > teleportDistance {
+			continue
+		}
+
+		if s, ok := speed(coordinates[i-1], coordinates[i]); ok && s > l.maxSpeed {
+			overspeedDistance += distance
+		}
+	}
+
+	return overspeedDistance > maxOverspeedDistance
+}
+
+func (l *limits) hasTeleport(coordinates []*entity.Coordinate) bool {
+	for i := 1; i < len(coordinates); i++ {
+		if geo.CoordinateDistance(coordinates[i-1], coordinates[i]) <= teleportDistance {
+			continue
+		}
+
+		if s, ok := speed(coordinates[i-1], coordinates[i]); !ok || s > l.maxSpeed {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (l *limits) beatsWorldRecord(distance, duration int) bool {
+	if duration <= 0 {
+		return false
+	}
+
+	for _, record := range l.recordPaces {
+		if distance >= record.distance {
+			return geo.Pace(float64(distance), duration) < record.pace
+		}
+	}
+
+	return false
+}
+
+// Fingerprint identifies a track by its coordinates rounded to about a meter,
+// so the same file uploaded twice yields the same value.
+func Fingerprint(coordinates []*entity.Coordinate) string {
+	if len(coordinates) < 2 {
+		return ""
+	}
+
+	hash := sha256.New()
+	for _, coordinate := range coordinates {
+		fmt.Fprintf(hash, "%.5f,%.5f;", coordinate.Lat, coordinate.Long)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,193 @@
+package anticheat
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"runmate_api/internal/entity"
+	"runmate_api/internal/geo"
+)
+
+var trackStart = time.Date(2024, 5, 1, 7, 0, 0, 0, time.UTC)
+
+// point is a position along a meridian, meters north of the equator, reached
+// seconds after the start. Negative seconds leave it without a timestamp.
+type point struct {
+	meters  float64
+	seconds float64
+}
+
+func track(points ...point) []*entity.Coordinate {
+	coordinates := make([]*entity.Coordinate, 0, len(points))
+	for _, p := range points {
+		coordinate := &entity.Coordinate{Lat: p.meters / (geo.EarthRadius * math.Pi / 180)}
+		if p.seconds >= 0 {
+			t := trackStart.Add(time.Duration(p.seconds * float64(time.Second)))
+			coordinate.Time = &t
+		}
+
+		coordinates = append(coordinates, coordinate)
+	}
+
+	return coordinates
+}
+
+// steady is a track covering distance meters at pace seconds per kilometer,
+// with a point every 100 meters.
+func steady(distance, pace float64) []*entity.Coordinate {
+	var points []point
+	for meters := 0.0; meters <= distance; meters += 100 {
+		points = append(points, point{meters, meters / 1000 * pace})
+	}
+
+	return track(points...)
+}
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		name     string
+		activity *entity.Activity
+		want     []string
+	}{
+		{
+			name:     "plausible run",
+			activity: &entity.Activity{Type: entity.ActivityTypeRun, Distance: 5000, Duration: 1500, Coordinates: steady(5000, 300)},
+		},
+		{
+			name:     "plausible ride at a speed no runner reaches",
+			activity: &entity.Activity{Type: entity.ActivityTypeRide, Distance: 20000, Duration: 1200, Coordinates: steady(20000, 60)},
+		},
+		{
+			name:     "run at riding speed",
+			activity: &entity.Activity{Type: entity.ActivityTypeRun, Distance: 5000, Duration: 300, Coordinates: steady(5000, 60)},
+			want:     []string{ReasonImpossibleSpeed, ReasonWorldRecordPace},
+		},
+		{
+			name: "short overspeed is GPS noise",
+			activity: &entity.Activity{
+				Type:        entity.ActivityTypeRun,
+				Distance:    1150,
+				Duration:    310,
+				Coordinates: track(point{0, 0}, point{150, 5}, point{1150, 310}),
+			},
+		},
+		{
+			name: "teleport without timestamps",
+			activity: &entity.Activity{
+				Type:        entity.ActivityTypeRun,
+				Distance:    5000,
+				Duration:    1500,
+				Coordinates: track(point{0, 0}, point{1000, 300}, point{4000, -1}, point{5000, 1500}),
+			},
+			want: []string{ReasonTeleport},
+		},
+		{
+			name: "teleport faster than the maximum speed",
+			activity: &entity.Activity{
+				Type:        entity.ActivityTypeRun,
+				Distance:    5000,
+				Duration:    1500,
+				Coordinates: track(point{0, 0}, point{1000, 300}, point{4000, 310}, point{5000, 1500}),
+			},
+			want: []string{ReasonTeleport},
+		},
+		{
+			name: "long gap explained by its timestamps",
+			activity: &entity.Activity{
+				Type:        entity.ActivityTypeRun,
+				Distance:    5000,
+				Duration:    1500,
+				Coordinates: track(point{0, 0}, point{1000, 300}, point{4000, 1200}, point{5000, 1500}),
+			},
+		},
+		{
+			name:     "world record pace",
+			activity: &entity.Activity{Type: entity.ActivityTypeRun, Distance: 10000, Duration: 1500},
+			want:     []string{ReasonWorldRecordPace},
+		},
+		{
+			name:     "just slower than the world record",
+			activity: &entity.Activity{Type: entity.ActivityTypeRun, Distance: 10000, Duration: 1580},
+		},
+		{
+			name:     "walks are held to running records",
+			activity: &entity.Activity{Type: entity.ActivityTypeWalk, Distance: 5000, Duration: 600},
+			want:     []string{ReasonWorldRecordPace},
+		},
+		{
+			name:     "ride below the cycling record",
+			activity: &entity.Activity{Type: entity.ActivityTypeRide, Distance: 40000, Duration: 2400},
+			want:     []string{ReasonWorldRecordPace},
+		},
+		{
+			name:     "distance without duration",
+			activity: &entity.Activity{Type: entity.ActivityTypeRun, Distance: 5000},
+			want:     []string{ReasonMissingDuration},
+		},
+		{
+			name:     "long run without a track",
+			activity: &entity.Activity{Type: entity.ActivityTypeRun, Distance: 15000, Duration: 4500},
+			want:     []string{ReasonUntimedDistance},
+		},
+		{
+			name: "long run with an untimed track",
+			activity: &entity.Activity{
+				Type:        entity.ActivityTypeRun,
+				Distance:    15000,
+				Duration:    4500,
+				Coordinates: track(point{0, -1}, point{500, -1}, point{1000, -1}),
+			},
+			want: []string{ReasonUntimedDistance},
+		},
+		{
+			name:     "long run without a track nor duration",
+			activity: &entity.Activity{Type: entity.ActivityTypeRun, Distance: 15000},
+			want:     []string{ReasonMissingDuration, ReasonUntimedDistance},
+		},
+		{
+			name:     "short treadmill run without a track",
+			activity: &entity.Activity{Type: entity.ActivityTypeTreadmill, Distance: 8000, Duration: 2400},
+		},
+		{
+			name:     "ride without a track within the riding limit",
+			activity: &entity.Activity{Type: entity.ActivityTypeRide, Distance: 30000, Duration: 3600},
+		},
+		{
+			name:     "empty activity",
+			activity: &entity.Activity{Type: entity.ActivityTypeRun},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Analyze(tt.activity); !slices.Equal(got, tt.want) {
+				t.Errorf("Analyze() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	coordinates := track(point{0, 0}, point{1000, 300}, point{2000, 600})
+	nudged := track(point{0.1, 10}, point{1000.1, 310}, point{2000.1, 610})
+	other := track(point{0, 0}, point{1000, 300}, point{2100, 600})
+
+	fingerprint := Fingerprint(coordinates)
+	if fingerprint == "" {
+		t.Fatal("Fingerprint() is empty")
+	}
+
+	if got := Fingerprint(nudged); got != fingerprint {
+		t.Errorf("Fingerprint() of the same track, nudged and at other times, = %q, want %q", got, fingerprint)
+	}
+
+	if got := Fingerprint(other); got == fingerprint {
+		t.Errorf("Fingerprint() of another track = %q, want a different one", got)
+	}
+
+	if got := Fingerprint(coordinates[:1]); got != "" {
+		t.Errorf("Fingerprint() of a single point = %q, want none", got)
+	}
+}
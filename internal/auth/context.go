@@ -0,0 +1,18 @@
+package auth
+
+import (
+	"context"
+
+	"runmate_api/internal/entity"
+)
+
+type contextKey struct{}
+
+func WithUser(ctx context.Context, user *entity.User) context.Context {
+	return context.WithValue(ctx, contextKey{}, user)
+}
+
+func UserFromContext(ctx context.Context) (*entity.User, bool) {
+	user, ok := ctx.Value(contextKey{}).(*entity.User)
+	return user, ok && user != nil
+}
@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"runmate_api/internal/entity"
+)
+
+type TokenType string
+
+const (
+	TokenTypeAccess  TokenType = "access"
+	TokenTypeRefresh TokenType = "refresh"
+
+	AccessTokenTTL  = 15 * time.Minute
+	RefreshTokenTTL = 30 * 24 * time.Hour
+
+	// MinSecretLength is the fewest bytes of the HS256 signing secret.
+	MinSecretLength = 32
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrWeakSecret   = fmt.Errorf("token secret must have at least %d bytes", MinSecretLength)
+)
+
+type Claims struct {
+	jwt.RegisteredClaims
+	Type TokenType `json:"typ"`
+	Role int8      `json:"role"`
+}
+
+type Tokens struct {
+	AccessToken      string
+	RefreshToken     string
+	AccessExpiresAt  time.Time
+	RefreshExpiresAt time.Time
+}
+
+type TokenManager struct {
+	secret []byte
+}
+
+func NewTokenManager(secret []byte) (*TokenManager, error) {
+	if len(secret) < MinSecretLength {
+		return nil, ErrWeakSecret
+	}
+
+	return &TokenManager{secret: secret}, nil
+}
+
+func (m *TokenManager) Issue(user *entity.User) (*Tokens, error) {
+	now := time.Now()
+
+	accessExpiresAt := now.Add(AccessTokenTTL)
+	accessToken, err := m.sign(user, TokenTypeAccess, now, accessExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshExpiresAt := now.Add(RefreshTokenTTL)
+	refreshToken, err := m.sign(user, TokenTypeRefresh, now, refreshExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Tokens{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		AccessExpiresAt:  accessExpiresAt,
+		RefreshExpiresAt: refreshExpiresAt,
+	}, nil
+}
+
+func (m *TokenManager) sign(user *entity.User, tokenType TokenType, issuedAt, expiresAt time.Time) (string, error) {
+	claims := &Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   user.ID.String(),
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		Type: tokenType,
+		Role: user.Role,
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(m.secret)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign %s token: %v", tokenType, err)
+	}
+
+	return token, nil
+}
+
+func (m *TokenManager) Parse(token string, tokenType TokenType) (*Claims, error) {
+	var claims Claims
+	_, err := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (any, error) {
+		return m.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	if claims.Type != tokenType {
+		return nil, fmt.Errorf("%w: expected %s token", ErrInvalidToken, tokenType)
+	}
+
+	return &claims, nil
+}
@@ -44,6 +44,20 @@ func (p messagePayload) ToEntity(challengeID string) (*entity.Message, error) {
 	}, nil
 }
 
+// NewUserMessage prepares a message the user sent on the websocket for
+// publishing. The sender is the authenticated user, whatever the payload says.
+func NewUserMessage(data []byte, userID uuid.UUID) ([]byte, error) {
+	var payload messagePayload
+	err := json.Unmarshal(data, &payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal message: %v", err)
+	}
+
+	payload.UserID = userID.String()
+	payload.Type = entity.MessageTypeUser
+	return json.Marshal(payload)
+}
+
 func getTopic(challengeID string) string {
 	return fmt.Sprintf("chat-challenge-%s", challengeID)
 }
@@ -1,28 +1,146 @@
 package entity
 
 import (
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+type ActivityReviewStatus int8
+
+const (
+	ActivityReviewStatusApproved ActivityReviewStatus = 0
+	ActivityReviewStatusPending  ActivityReviewStatus = 1
+	ActivityReviewStatusRejected ActivityReviewStatus = 2
+)
+
+type ActivityType int8
+
+const (
+	ActivityTypeRun       ActivityType = 0
+	ActivityTypeWalk      ActivityType = 1
+	ActivityTypeHike      ActivityType = 2
+	ActivityTypeRide      ActivityType = 3
+	ActivityTypeTreadmill ActivityType = 4
+)
+
+type ActivityVisibility int8
+
+const (
+	ActivityVisibilityPublic  ActivityVisibility = 0
+	ActivityVisibilityFriends ActivityVisibility = 1
+	ActivityVisibilityPrivate ActivityVisibility = 2
+)
+
 type Activity struct {
-	ID          uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
-	UserID      uuid.UUID `gorm:"type:uuid;not null"`
-	Title       string
-	Date        time.Time
-	Duration    int
-	Distance    int
-	Coordinates []*Coordinate `gorm:"foreignKey:ActivityID;constraint:OnDelete:CASCADE"`
-	User        *User         `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	ID             uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID         uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_activities_idempotency_key"`
+	IdempotencyKey *string   `gorm:"uniqueIndex:idx_activities_idempotency_key"`
+	Title          string
+	Type           ActivityType `gorm:"index"`
+	Date           time.Time    `gorm:"index"`
+	Duration       int
+	Distance       int
+	ElevationGain  int
+	TrackHash      string `gorm:"index"`
+	ReviewStatus   ActivityReviewStatus
+	ReviewReasons  []string `gorm:"serializer:json"`
+	Visibility     ActivityVisibility
+	KudosCount     int              `gorm:"->;-:migration"`
+	CommentCount   int              `gorm:"->;-:migration"`
+	Coordinates    []*Coordinate    `gorm:"foreignKey:ActivityID;constraint:OnDelete:CASCADE"`
+	Kudos          []*Kudos         `gorm:"foreignKey:ActivityID;constraint:OnDelete:CASCADE"`
+	Comments       []*Comment       `gorm:"foreignKey:ActivityID;constraint:OnDelete:CASCADE"`
+	Media          []*ActivityMedia `gorm:"foreignKey:ActivityID;constraint:OnDelete:CASCADE"`
+	BestEfforts    []*BestEffort    `gorm:"foreignKey:ActivityID;constraint:OnDelete:CASCADE"`
+	User           *User            `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+// ActivityCursor points at the last activity of a page, in the order of
+// activity listings: newest date first, then descending ID.
+type ActivityCursor struct {
+	Date time.Time
+	ID   uuid.UUID
+}
+
+type ActivityFilter struct {
+	// Types keeps only activities of these types. Empty keeps all of them.
+	Types []ActivityType
+	// From and To keep only activities dated in [From, To).
+	From *time.Time
+	To   *time.Time
+	// After keeps only activities listed after the cursor.
+	After *ActivityCursor
+	// Limit is the page size. Zero lists every activity.
+	Limit int
+	// WithoutCoordinates leaves the tracks out.
+	WithoutCoordinates bool
+}
+
+type ActivityPage struct {
+	Activities []*Activity
+	// Next is the cursor of the following page, or nil on the last one.
+	Next *ActivityCursor
+	// LevelUps are reached in the time the activities of a feed page span.
+	LevelUps []*LevelUp
+}
+
+// NewActivityPage cuts activities, listed with one more than the filter limit,
+// into a page.
+func NewActivityPage(activities []*Activity, filter *ActivityFilter) *ActivityPage {
+	if filter == nil || filter.Limit <= 0 || len(activities) <= filter.Limit {
+		return &ActivityPage{Activities: activities}
+	}
+
+	activities = activities[:filter.Limit]
+	last := activities[len(activities)-1]
+	return &ActivityPage{
+		Activities: activities,
+		Next:       &ActivityCursor{Date: last.Date, ID: last.ID},
+	}
 }
 
+var (
+	ErrCoordinateTimeNotMonotonic = errors.New("coordinate timestamps must not go back in time")
+	ErrNegativeActivityMeasure    = errors.New("distance and duration must not be negative")
+)
+
 type Coordinate struct {
 	ID         uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
 	ActivityID uuid.UUID `gorm:"type:uuid;not null"`
 	Lat        float64
 	Long       float64
 	Order      int
+	Time       *time.Time
+	Altitude   *float64
+	HeartRate  *int
+	Cadence    *int
+}
+
+func (a *Activity) EndDate() time.Time {
+	return a.Date.Add(time.Duration(a.Duration) * time.Second)
+}
+
+func (a *Activity) Validate() error {
+	if a.Distance < 0 || a.Duration < 0 {
+		return ErrNegativeActivityMeasure
+	}
+
+	var last *time.Time
+	for _, coordinate := range a.Coordinates {
+		if coordinate.Time == nil {
+			continue
+		}
+
+		if last != nil && coordinate.Time.Before(*last) {
+			return ErrCoordinateTimeNotMonotonic
+		}
+
+		last = coordinate.Time
+	}
+
+	return nil
 }
 
 type UserDayActitivy struct {
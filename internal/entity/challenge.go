@@ -1,6 +1,7 @@
 package entity
 
 import (
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
@@ -21,6 +22,7 @@ type Challenge struct {
 	EndDate       *time.Time
 	Type          ChallengeType
 	TotalDistance *int
+	ActivityTypes []ActivityType `gorm:"serializer:json"`
 	CreatedBy     uuid.UUID
 	CreatedAt     time.Time
 	UpdatedAt     time.Time
@@ -28,10 +30,17 @@ type Challenge struct {
 	Events        []*ChallengeEvent `gorm:"foreignKey:ChallengeID;constraint:OnDelete:CASCADE"`
 }
 
+// Accepts tells whether activities of the given type count toward the
+// challenge. Challenges without activity types accept all of them.
+func (c *Challenge) Accepts(activityType ActivityType) bool {
+	return len(c.ActivityTypes) == 0 || slices.Contains(c.ActivityTypes, activityType)
+}
+
 type ChallengeEvent struct {
-	ID          uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
-	ChallengeID uuid.UUID `gorm:"type:uuid;not null"`
-	UserID      uuid.UUID `gorm:"type:uuid;not null"`
+	ID          uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	ChallengeID uuid.UUID  `gorm:"type:uuid;not null"`
+	UserID      uuid.UUID  `gorm:"type:uuid;not null"`
+	ActivityID  *uuid.UUID `gorm:"type:uuid;index"`
 	Distance    int
 	Date        time.Time
 }
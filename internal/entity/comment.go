@@ -0,0 +1,39 @@
+package entity
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const CommentMaxLength = 2000
+
+var (
+	ErrInvalidComment = errors.New("comment must have between 1 and 2000 characters")
+)
+
+// Comment belongs to an activity and, when it is a reply, to its parent
+// comment. Deleting a comment deletes its replies.
+type Comment struct {
+	ID         uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	ActivityID uuid.UUID  `gorm:"type:uuid;not null;index"`
+	UserID     uuid.UUID  `gorm:"type:uuid;not null"`
+	ParentID   *uuid.UUID `gorm:"type:uuid;index"`
+	Content    string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	User       *User      `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Replies    []*Comment `gorm:"foreignKey:ParentID;constraint:OnDelete:CASCADE"`
+}
+
+func (c *Comment) Validate() error {
+	content := strings.TrimSpace(c.Content)
+	if content == "" || len([]rune(content)) > CommentMaxLength {
+		return ErrInvalidComment
+	}
+
+	c.Content = content
+	return nil
+}
@@ -0,0 +1,111 @@
+package entity
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type GoalPeriod int8
+
+const (
+	GoalPeriodDay   GoalPeriod = 0
+	GoalPeriodWeek  GoalPeriod = 1
+	GoalPeriodMonth GoalPeriod = 2
+)
+
+// MaxDays is the most days the period may have.
+func (p GoalPeriod) MaxDays() int {
+	switch p {
+	case GoalPeriodDay:
+		return 1
+	case GoalPeriodWeek:
+		return 7
+	default:
+		return 31
+	}
+}
+
+type GoalMetric int8
+
+const (
+	// GoalMetricDistance sums the distance in meters.
+	GoalMetricDistance GoalMetric = 0
+	// GoalMetricDuration sums the duration in seconds.
+	GoalMetricDuration GoalMetric = 1
+	// GoalMetricActivities counts the activities.
+	GoalMetricActivities GoalMetric = 2
+	// GoalMetricActiveDays counts the days with activities summing at least
+	// the daily distance of the goal.
+	GoalMetricActiveDays GoalMetric = 3
+)
+
+const GoalMaxActive = 10
+
+var (
+	ErrInvalidGoal = errors.New("invalid goal")
+)
+
+// Goal is a target of the metric to reach in every period, such as 100 km a
+// month or 4 runs a week. Ended goals are kept as the history of the user.
+type Goal struct {
+	ID            uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID        uuid.UUID `gorm:"type:uuid;not null;index"`
+	Period        GoalPeriod
+	Metric        GoalMetric
+	Target        int
+	DailyDistance *int
+	ActivityTypes []ActivityType `gorm:"serializer:json"`
+	CreatedAt     time.Time
+	EndedAt       *time.Time
+	Progress      *GoalProgress `gorm:"-:all"`
+}
+
+func (g *Goal) Validate() error {
+	if g.Period < GoalPeriodDay || g.Period > GoalPeriodMonth {
+		return ErrInvalidGoal
+	}
+
+	if g.Metric < GoalMetricDistance || g.Metric > GoalMetricActiveDays || g.Target <= 0 {
+		return ErrInvalidGoal
+	}
+
+	if g.DailyDistance != nil && (g.Metric != GoalMetricActiveDays || *g.DailyDistance <= 0) {
+		return ErrInvalidGoal
+	}
+
+	if g.Metric == GoalMetricActiveDays && g.Target > g.Period.MaxDays() {
+		return ErrInvalidGoal
+	}
+
+	return nil
+}
+
+// Accepts tells whether activities of the given type count toward the goal.
+// Goals without activity types accept all of them.
+func (g *Goal) Accepts(activityType ActivityType) bool {
+	return len(g.ActivityTypes) == 0 || slices.Contains(g.ActivityTypes, activityType)
+}
+
+// GoalPeriodProgress is the progress of the goal in the period starting at
+// Start.
+type GoalPeriodProgress struct {
+	Start time.Time
+	Value int
+	Met   bool
+}
+
+// GoalProgress holds the latest periods of the goal, ending with the current
+// one, and its streaks of consecutive periods met. The current period only
+// breaks the streak once it is over.
+type GoalProgress struct {
+	Periods       []*GoalPeriodProgress
+	CurrentStreak int
+	LongestStreak int
+}
+
+func (g *GoalProgress) CurrentPeriod() *GoalPeriodProgress {
+	return g.Periods[len(g.Periods)-1]
+}
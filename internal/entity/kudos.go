@@ -0,0 +1,14 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Kudos struct {
+	ActivityID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID     uuid.UUID `gorm:"type:uuid;primaryKey"`
+	CreatedAt  time.Time
+	User       *User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
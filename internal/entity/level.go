@@ -0,0 +1,87 @@
+package entity
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidLevelRewards = errors.New("invalid level rewards")
+	ErrInvalidProfileFrame = errors.New("profile frame not unlocked")
+)
+
+// LevelUp is reached by the user the first time their XP takes them to the
+// level, and is shown in the feed of their friends. Its date is the one of the
+// activity that reached it or, for XP from anything else, when it was granted,
+// so it sorts among the activities of the feed.
+type LevelUp struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index"`
+	Level     int
+	Date      time.Time `gorm:"index"`
+	CreatedAt time.Time
+	User      *User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+// LevelReward is unlocked by reaching the level: a profile frame the user may
+// pick, streak freezes, or both.
+type LevelReward struct {
+	Level         int
+	ProfileFrame  string
+	StreakFreezes int
+}
+
+// LevelRewards is the reward table of the levels.
+type LevelRewards []*LevelReward
+
+// DefaultLevelRewards is the reward table used unless another is configured.
+var DefaultLevelRewards = LevelRewards{
+	{Level: 5, ProfileFrame: "bronze"},
+	{Level: 10, StreakFreezes: 1},
+	{Level: 15, ProfileFrame: "silver"},
+	{Level: 20, StreakFreezes: 1},
+	{Level: 25, ProfileFrame: "gold"},
+	{Level: 30, StreakFreezes: 1},
+	{Level: 50, ProfileFrame: "diamond"},
+}
+
+func (r LevelRewards) Validate() error {
+	for _, reward := range r {
+		if reward.Level < 2 || reward.StreakFreezes < 0 {
+			return ErrInvalidLevelRewards
+		}
+
+		if reward.ProfileFrame == "" && reward.StreakFreezes == 0 {
+			return ErrInvalidLevelRewards
+		}
+	}
+
+	return nil
+}
+
+// At lists the rewards of the level.
+func (r LevelRewards) At(level int) []*LevelReward {
+	var rewards []*LevelReward
+	for _, reward := range r {
+		if reward.Level == level {
+			rewards = append(rewards, reward)
+		}
+	}
+
+	return rewards
+}
+
+// ProfileFrames lists the profile frames unlocked up to the level.
+func (r LevelRewards) ProfileFrames(level int) []string {
+	var frames []string
+	for _, reward := range r {
+		if reward.Level <= level && reward.ProfileFrame != "" && !slices.Contains(frames, reward.ProfileFrame) {
+			frames = append(frames, reward.ProfileFrame)
+		}
+	}
+
+	return frames
+}
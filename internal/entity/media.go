@@ -0,0 +1,26 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const ActivityMediaMaxCount = 10
+
+// ActivityMedia is a photo attached to an activity. The image and its
+// thumbnail are kept in the blob storage under Key and ThumbnailKey.
+type ActivityMedia struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey"`
+	ActivityID   uuid.UUID `gorm:"type:uuid;not null;index"`
+	UserID       uuid.UUID `gorm:"type:uuid;not null"`
+	Key          string
+	ThumbnailKey string
+	URL          string
+	ThumbnailURL string
+	ContentType  string
+	Size         int
+	Width        int
+	Height       int
+	CreatedAt    time.Time
+}
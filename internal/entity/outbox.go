@@ -0,0 +1,22 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OutboxNotification is a push notification to a single device, stored with
+// the changes that caused it and delivered later by the outbox dispatcher.
+type OutboxNotification struct {
+	ID            uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	Title         string
+	Body          string
+	Token         string
+	Attempts      int
+	LastError     string
+	NextAttemptAt time.Time `gorm:"index"`
+	SentAt        *time.Time
+	FailedAt      *time.Time
+	CreatedAt     time.Time
+}
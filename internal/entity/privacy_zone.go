@@ -0,0 +1,41 @@
+package entity
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	PrivacyZoneMinRadius = 100
+	PrivacyZoneMaxRadius = 5000
+)
+
+var (
+	ErrInvalidPrivacyZone = errors.New("invalid privacy zone")
+)
+
+// PrivacyZone is a circle, such as around home or work, whose coordinates are
+// hidden from the tracks other users see.
+type PrivacyZone struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index"`
+	Name      string
+	Lat       float64
+	Long      float64
+	Radius    int
+	CreatedAt time.Time
+}
+
+func (p *PrivacyZone) Validate() error {
+	if p.Lat < -90 || p.Lat > 90 || p.Long < -180 || p.Long > 180 {
+		return ErrInvalidPrivacyZone
+	}
+
+	if p.Radius < PrivacyZoneMinRadius || p.Radius > PrivacyZoneMaxRadius {
+		return ErrInvalidPrivacyZone
+	}
+
+	return nil
+}
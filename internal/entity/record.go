@@ -0,0 +1,65 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type RecordKind int8
+
+const (
+	RecordKind1K           RecordKind = 0
+	RecordKind5K           RecordKind = 1
+	RecordKind10K          RecordKind = 2
+	RecordKindHalfMarathon RecordKind = 3
+	RecordKindMarathon     RecordKind = 4
+	RecordKindLongestRun   RecordKind = 5
+)
+
+// RecordDistances are the distances, in meters, whose fastest effort is a
+// record. RecordKindLongestRun is the whole distance of the activity instead.
+var RecordDistances = map[RecordKind]float64{
+	RecordKind1K:           1000,
+	RecordKind5K:           5000,
+	RecordKind10K:          10000,
+	RecordKindHalfMarathon: 21097.5,
+	RecordKindMarathon:     42195,
+}
+
+// CountsForRecords tells whether activities of the type are runs, the only
+// ones considered for records.
+func (t ActivityType) CountsForRecords() bool {
+	return t == ActivityTypeRun || t == ActivityTypeTreadmill
+}
+
+// BestEffort is the fastest an activity covered one of the record distances
+// or, for RecordKindLongestRun, the activity itself. The personal records of
+// a user are their best efforts of each kind.
+type BestEffort struct {
+	ID         uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	ActivityID uuid.UUID  `gorm:"type:uuid;not null;index"`
+	UserID     uuid.UUID  `gorm:"type:uuid;not null;index:idx_best_efforts_user_kind"`
+	Kind       RecordKind `gorm:"index:idx_best_efforts_user_kind"`
+	Distance   int
+	Duration   int
+	Date       time.Time
+	// PersonalRecord tells whether the effort beat the previous record of
+	// the user when the activity was stored.
+	PersonalRecord bool
+	CreatedAt      time.Time
+	Activity       *Activity `gorm:"foreignKey:ActivityID"`
+}
+
+// Beats tells whether the effort is better than the record, which may be nil.
+func (b *BestEffort) Beats(record *BestEffort) bool {
+	if record == nil {
+		return true
+	}
+
+	if b.Kind == RecordKindLongestRun {
+		return b.Distance > record.Distance
+	}
+
+	return b.Duration < record.Duration
+}
@@ -0,0 +1,23 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ReminderKind int8
+
+const (
+	ReminderKindGoalAtRisk   ReminderKind = 0
+	ReminderKindStreakAtRisk ReminderKind = 1
+)
+
+// Reminder records a reminder sent to the user on Day, in their time zone,
+// so each kind is sent at most once a day.
+type Reminder struct {
+	UserID    uuid.UUID    `gorm:"type:uuid;primaryKey"`
+	Kind      ReminderKind `gorm:"primaryKey"`
+	Day       string       `gorm:"primaryKey"`
+	CreatedAt time.Time
+}
@@ -0,0 +1,44 @@
+package entity
+
+import "time"
+
+// TotalsPeriod is how activity totals are grouped, named after the fields of
+// the Postgres date_trunc function. TotalsPeriodAll does not group them.
+type TotalsPeriod string
+
+const (
+	TotalsPeriodAll   TotalsPeriod = ""
+	TotalsPeriodDay   TotalsPeriod = "day"
+	TotalsPeriodWeek  TotalsPeriod = "week"
+	TotalsPeriodMonth TotalsPeriod = "month"
+	TotalsPeriodYear  TotalsPeriod = "year"
+)
+
+type TotalsFilter struct {
+	Period TotalsPeriod
+	// Location and WeekStart define where days, weeks, months and years
+	// start.
+	Location  *time.Location
+	WeekStart time.Weekday
+	From      *time.Time
+	Types     []ActivityType
+}
+
+// ActivityTotals sums the approved activities of the period starting at
+// Start.
+type ActivityTotals struct {
+	Start         time.Time
+	Count         int
+	Distance      int
+	Duration      int
+	ElevationGain int
+}
+
+type UserStats struct {
+	TimeZone  string
+	WeekStart time.Weekday
+	Weeks     []*ActivityTotals
+	Months    []*ActivityTotals
+	Years     []*ActivityTotals
+	AllTime   *ActivityTotals
+}
@@ -0,0 +1,46 @@
+package entity
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type StreakKind int8
+
+const (
+	StreakKindDaily  StreakKind = 0
+	StreakKindWeekly StreakKind = 1
+)
+
+const (
+	// StreakFreezeXP is the XP earning each streak freeze.
+	StreakFreezeXP = 5000
+	// StreakFreezeMax is the most streak freezes a user may hold.
+	StreakFreezeMax = 3
+)
+
+// StreakActivityTypes are the runs, the only activities keeping streaks.
+var StreakActivityTypes = []ActivityType{ActivityTypeRun, ActivityTypeTreadmill}
+
+func (t ActivityType) CountsForStreaks() bool {
+	return slices.Contains(StreakActivityTypes, t)
+}
+
+// Streak counts consecutive days or weeks with runs. Periods covered by a
+// streak freeze keep the streak without adding to it, and the current period
+// only breaks it once it is over.
+type Streak struct {
+	Current int
+	Longest int
+}
+
+// StreakFreeze records a day or week, starting on Start in the time zone of
+// the user, missed without breaking the streak by spending a streak freeze.
+type StreakFreeze struct {
+	UserID    uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	Kind      StreakKind `gorm:"primaryKey"`
+	Start     string     `gorm:"primaryKey"`
+	CreatedAt time.Time
+}
@@ -1,21 +1,30 @@
 package entity
 
 import (
+	"crypto/subtle"
 	"errors"
+	"fmt"
 	"math"
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
 )
 
 const (
 	UserRoleUser  = 0
 	UserRoleAdmin = 1
+
+	DefaultTimeZone = "America/Sao_Paulo"
 )
 
 var (
 	usernameRegex = regexp.MustCompile(`^[a-zA-Z0-9_]{3,16}$`)
+
+	ErrInvalidTimeZone  = errors.New("invalid time zone")
+	ErrInvalidWeekStart = errors.New("week must start on sunday or monday")
 )
 
 type User struct {
@@ -27,8 +36,13 @@ type User struct {
 	FCMToken          string
 	Role              int8
 	XP                int
-	GoalDays          *int
-	GoalDailyDistance *int
+	StreakFreezes     int
+	FreezesEarned     int
+	LevelReached      int
+	ProfileFrame      string
+	ProfileFrames     []string     `gorm:"-:all"`
+	TimeZone          string       `gorm:"default:America/Sao_Paulo"`
+	WeekStart         time.Weekday `gorm:"default:0"`
 	Birthdate         time.Time
 	CreatedAt         time.Time
 	UpdatedAt         time.Time
@@ -38,7 +52,12 @@ type User struct {
 	CreatedChallenges []*Challenge       `gorm:"foreignKey:CreatedBy;constraint:OnDelete:CASCADE"`
 	ChallengeEvents   []*ChallengeEvent  `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
 	Events            []*Event           `gorm:"many2many:user_events;constraint:OnDelete:CASCADE"`
+	PrivacyZones      []*PrivacyZone     `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Goals             []*Goal            `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	XPTransactions    []*XPTransaction   `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
 	WeekActivities    []*UserDayActitivy `gorm:"-:all"`
+	DailyStreak       *Streak            `gorm:"-:all"`
+	WeeklyStreak      *Streak            `gorm:"-:all"`
 }
 
 func (u *User) Validate() error {
@@ -46,9 +65,70 @@ func (u *User) Validate() error {
 		return errors.New("invalid username")
 	}
 
+	if _, err := time.LoadLocation(u.TimeZone); err != nil {
+		return ErrInvalidTimeZone
+	}
+
+	if u.WeekStart != time.Sunday && u.WeekStart != time.Monday {
+		return ErrInvalidWeekStart
+	}
+
 	return nil
 }
 
+// Location is the time zone days and weeks of the user are counted in.
+func (u *User) Location() *time.Location {
+	location, err := time.LoadLocation(u.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+
+	return location
+}
+
+// StartOfDay is the midnight starting the day of t for the user.
+func (u *User) StartOfDay(t time.Time) time.Time {
+	t = t.In(u.Location())
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// StartOfWeek is the midnight starting the week of t for the user.
+func (u *User) StartOfWeek(t time.Time) time.Time {
+	day := u.StartOfDay(t)
+	offset := (int(day.Weekday()) - int(u.WeekStart) + 7) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+// StartOfMonth is the midnight starting the month of t for the user.
+func (u *User) StartOfMonth(t time.Time) time.Time {
+	day := u.StartOfDay(t)
+	return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, day.Location())
+}
+
+func (u *User) HasHashedPassword() bool {
+	return strings.HasPrefix(u.Password, "$2")
+}
+
+func (u *User) SetPassword(password string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %v", err)
+	}
+
+	u.Password = string(hash)
+	return nil
+}
+
+// CheckPassword also accepts legacy plaintext passwords, so callers must check
+// HasHashedPassword and rehash them after a successful match.
+func (u *User) CheckPassword(password string) bool {
+	if !u.HasHashedPassword() {
+		return subtle.ConstantTimeCompare([]byte(u.Password), []byte(password)) == 1
+	}
+
+	return bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password)) == nil
+}
+
 func (u *User) CurrentLevel() int {
 	return int(math.Sqrt(float64(1000*(2*u.XP+250)))+500) / 1000
 }
@@ -60,3 +140,9 @@ func (u *User) levelXP(level int) int {
 func (u *User) NextLevelXP() int {
 	return u.levelXP(u.CurrentLevel()+1) - u.XP
 }
+
+// HighestLevel is the highest level the user reached, which XP taken back
+// does not lower.
+func (u *User) HighestLevel() int {
+	return max(u.LevelReached, u.CurrentLevel())
+}
@@ -0,0 +1,42 @@
+package entity
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// XPSource values are stored in the ledger, so they are never renumbered.
+type XPSource int8
+
+const (
+	XPSourceActivity        XPSource = 0
+	XPSourceAdminAdjustment XPSource = 3
+	// XPSourceLevelUp entries record the levels reached, with no amount.
+	XPSourceLevelUp XPSource = 4
+)
+
+var (
+	ErrInvalidXPAdjustment = errors.New("invalid xp adjustment")
+)
+
+// XPTransaction is an entry of the append-only XP ledger of the user, whose
+// XP is the sum of its entries. Taking XP back adds an entry with a negative
+// amount for the same source.
+type XPTransaction struct {
+	ID        uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;index"`
+	Source    XPSource   `gorm:"index:idx_xp_transactions_source"`
+	SourceID  *uuid.UUID `gorm:"type:uuid;index:idx_xp_transactions_source"`
+	Amount    int
+	Reason    string
+	CreatedBy *uuid.UUID `gorm:"type:uuid"`
+	CreatedAt time.Time
+}
+
+// XPSourceTotal sums the entries of the ledger of one source.
+type XPSourceTotal struct {
+	Count  int
+	Amount int
+}
@@ -0,0 +1,52 @@
+package geo
+
+import (
+	"math"
+
+	"runmate_api/internal/entity"
+)
+
+// BestEfforts finds, for each distance in meters, the fewest seconds the
+// timestamped track took to cover it, starting anywhere along the track.
+// Distances longer than the track are left out.
+func BestEfforts(coordinates []*entity.Coordinate, distances []float64) map[float64]float64 {
+	p := newProfile(coordinates)
+	efforts := make(map[float64]float64, len(distances))
+	if len(p.distances) < 2 {
+		return efforts
+	}
+
+	for _, distance := range distances {
+		if seconds, ok := p.bestEffort(distance); ok {
+			efforts[distance] = seconds
+		}
+	}
+
+	return efforts
+}
+
+// bestEffort slides a window of the given distance along the track. Time is
+// linear between points, so the fastest window starts or ends at one of them.
+// The profile starts at the first timestamped point, which may lie past the
+// start of the track, so windows never reach before it.
+func (p *profile) bestEffort(distance float64) (float64, bool) {
+	first, total := p.distances[0], p.total()
+	if distance <= 0 || distance > total-first {
+		return 0, false
+	}
+
+	best := math.Inf(1)
+	for i, start := range p.distances {
+		if start+distance <= total {
+			endSeconds, _ := p.at(start + distance)
+			best = math.Min(best, endSeconds-p.seconds[i])
+		}
+
+		if end := p.distances[i]; end-first >= distance {
+			startSeconds, _ := p.at(end - distance)
+			best = math.Min(best, p.seconds[i]-startSeconds)
+		}
+	}
+
+	return best, !math.IsInf(best, 1)
+}
@@ -0,0 +1,58 @@
+package geo
+
+import "testing"
+
+func TestBestEfforts(t *testing.T) {
+	tests := []struct {
+		name      string
+		points    []point
+		distances []float64
+		want      map[float64]float64
+	}{
+		{
+			name:      "steady",
+			points:    []point{{0, 0}, {1000, 300}, {2000, 600}, {2100, 630}},
+			distances: []float64{1000, 2000},
+			want:      map[float64]float64{1000: 300, 2000: 600},
+		},
+		{
+			name:      "fastest window inside the track",
+			points:    []point{{0, 0}, {1000, 330}, {2000, 600}, {3000, 930}},
+			distances: []float64{1000, 1500},
+			want:      map[float64]float64{1000: 270, 1500: 435},
+		},
+		{
+			name:      "longer than the track",
+			points:    []point{{0, 0}, {1100, 330}},
+			distances: []float64{1000, 5000},
+			want:      map[float64]float64{1000: 300},
+		},
+		{
+			name:      "untimed prefix is left out",
+			points:    []point{{0, -1}, {500, -1}, {500, 0}, {1600, 330}},
+			distances: []float64{1000, 1500},
+			want:      map[float64]float64{1000: 300},
+		},
+		{
+			name:      "untimed",
+			points:    []point{{0, -1}, {1000, -1}},
+			distances: []float64{1000},
+			want:      map[float64]float64{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			efforts := BestEfforts(track(tt.points...), tt.distances)
+			if len(efforts) != len(tt.want) {
+				t.Fatalf("BestEfforts() = %v, want %v", efforts, tt.want)
+			}
+
+			for distance, want := range tt.want {
+				if got, ok := efforts[distance]; !ok || !almostEqual(got, want) {
+					t.Errorf("effort for %v = %v, want %v", distance, got, want)
+				}
+			}
+		})
+	}
+}
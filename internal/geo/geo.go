@@ -0,0 +1,46 @@
+package geo
+
+import (
+	"math"
+
+	"runmate_api/internal/entity"
+)
+
+const EarthRadius = 6371008.8 // Mean earth radius in meters
+
+func toRadians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+// Distance returns the haversine distance, in meters, between two points.
+func Distance(lat1, long1, lat2, long2 float64) float64 {
+	dLat := toRadians(lat2 - lat1)
+	dLong := toRadians(long2 - long1)
+
+	a := math.Pow(math.Sin(dLat/2), 2) + math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Pow(math.Sin(dLong/2), 2)
+	return 2 * EarthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+func CoordinateDistance(from, to *entity.Coordinate) float64 {
+	return Distance(from.Lat, from.Long, to.Lat, to.Long)
+}
+
+// PathDistance returns the distance, in meters, along the ordered coordinates.
+func PathDistance(coordinates []*entity.Coordinate) float64 {
+	var total float64
+	for i := 1; i < len(coordinates); i++ {
+		total += CoordinateDistance(coordinates[i-1], coordinates[i])
+	}
+
+	return total
+}
+
+// Pace returns the pace, in seconds per kilometer, for the given distance in
+// meters and duration in seconds.
+func Pace(distance float64, duration int) float64 {
+	if distance <= 0 {
+		return 0
+	}
+
+	return float64(duration) / (distance / 1000)
+}
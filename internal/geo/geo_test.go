@@ -0,0 +1,73 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDistance(t *testing.T) {
+	degree := EarthRadius * math.Pi / 180
+	tests := []struct {
+		name                     string
+		lat1, long1, lat2, long2 float64
+		want                     float64
+	}{
+		{name: "same point", lat1: -23.55, long1: -46.63, lat2: -23.55, long2: -46.63, want: 0},
+		{name: "degree of latitude", lat1: 0, long1: 0, lat2: 1, long2: 0, want: degree},
+		{name: "degree of longitude at the equator", lat1: 0, long1: 0, lat2: 0, long2: 1, want: degree},
+		{name: "degree of longitude at 60 degrees", lat1: 60, long1: 0, lat2: 60, long2: 1, want: 55597.01},
+		{name: "antipodes", lat1: 0, long1: 0, lat2: 0, long2: 180, want: math.Pi * EarthRadius},
+		{name: "across the antimeridian", lat1: 0, long1: 179.5, lat2: 0, long2: -179.5, want: degree},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Distance(tt.lat1, tt.long1, tt.lat2, tt.long2)
+			if !almostEqual(got, tt.want) {
+				t.Errorf("Distance() = %v, want %v", got, tt.want)
+			}
+
+			if back := Distance(tt.lat2, tt.long2, tt.lat1, tt.long1); !almostEqual(back, got) {
+				t.Errorf("Distance() back = %v, want %v", back, got)
+			}
+		})
+	}
+}
+
+func TestPathDistance(t *testing.T) {
+	tests := []struct {
+		name   string
+		points []point
+		want   float64
+	}{
+		{name: "empty", want: 0},
+		{name: "single point", points: []point{{0, -1}}, want: 0},
+		{name: "out and back", points: []point{{0, -1}, {1000, -1}, {500, -1}}, want: 1500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PathDistance(track(tt.points...)); !almostEqual(got, tt.want) {
+				t.Errorf("PathDistance() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPace(t *testing.T) {
+	tests := []struct {
+		distance float64
+		duration int
+		want     float64
+	}{
+		{distance: 5000, duration: 1500, want: 300},
+		{distance: 500, duration: 150, want: 300},
+		{distance: 0, duration: 600, want: 0},
+	}
+
+	for _, tt := range tests {
+		if got := Pace(tt.distance, tt.duration); !almostEqual(got, tt.want) {
+			t.Errorf("Pace(%v, %v) = %v, want %v", tt.distance, tt.duration, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package geo
+
+import (
+	"math"
+	"strings"
+
+	"runmate_api/internal/entity"
+)
+
+const polylinePrecision = 1e5
+
+// EncodePolyline encodes the coordinates in Google's encoded polyline format,
+// with five decimal places.
+func EncodePolyline(coordinates []*entity.Coordinate) string {
+	var b strings.Builder
+	var lastLat, lastLong int
+	for _, coordinate := range coordinates {
+		lat := int(math.Round(coordinate.Lat * polylinePrecision))
+		long := int(math.Round(coordinate.Long * polylinePrecision))
+
+		encodePolylineValue(&b, lat-lastLat)
+		encodePolylineValue(&b, long-lastLong)
+		lastLat, lastLong = lat, long
+	}
+
+	return b.String()
+}
+
+func encodePolylineValue(b *strings.Builder, value int) {
+	shifted := value << 1
+	if value < 0 {
+		shifted = ^shifted
+	}
+
+	for shifted >= 0x20 {
+		b.WriteByte(byte((0x20 | (shifted & 0x1f)) + 63))
+		shifted >>= 5
+	}
+
+	b.WriteByte(byte(shifted + 63))
+}
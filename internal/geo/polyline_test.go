@@ -0,0 +1,48 @@
+package geo
+
+import (
+	"testing"
+
+	"runmate_api/internal/entity"
+)
+
+func TestEncodePolyline(t *testing.T) {
+	tests := []struct {
+		name        string
+		coordinates []*entity.Coordinate
+		want        string
+	}{
+		{
+			// The example of the format documentation.
+			name: "documentation example",
+			coordinates: []*entity.Coordinate{
+				{Lat: 38.5, Long: -120.2},
+				{Lat: 40.7, Long: -120.95},
+				{Lat: 43.252, Long: -126.453},
+			},
+			want: "_p~iF~ps|U_ulLnnqC_mqNvxq`@",
+		},
+		{
+			name:        "rounds to five decimal places",
+			coordinates: []*entity.Coordinate{{Lat: 38.500004, Long: -120.199996}},
+			want:        "_p~iF~ps|U",
+		},
+		{
+			name:        "origin",
+			coordinates: []*entity.Coordinate{{Lat: 0, Long: 0}},
+			want:        "??",
+		},
+		{
+			name: "empty",
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EncodePolyline(tt.coordinates); got != tt.want {
+				t.Errorf("EncodePolyline() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
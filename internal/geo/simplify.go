@@ -0,0 +1,73 @@
+package geo
+
+import (
+	"math"
+
+	"runmate_api/internal/entity"
+)
+
+// Simplify reduces the track with the Douglas-Peucker algorithm, dropping the
+// coordinates that lie within tolerance meters of the simplified line. The
+// first and last coordinates are always kept.
+func Simplify(coordinates []*entity.Coordinate, tolerance float64) []*entity.Coordinate {
+	if len(coordinates) < 3 {
+		return coordinates
+	}
+
+	keep := make([]bool, len(coordinates))
+	keep[0], keep[len(coordinates)-1] = true, true
+
+	// Ranges still to be simplified, as pairs of indexes.
+	stack := [][2]int{{0, len(coordinates) - 1}}
+	for len(stack) > 0 {
+		first, last := stack[len(stack)-1][0], stack[len(stack)-1][1]
+		stack = stack[:len(stack)-1]
+
+		farthest, maxDistance := -1, tolerance
+		for i := first + 1; i < last; i++ {
+			distance := segmentDistance(coordinates[i], coordinates[first], coordinates[last])
+			if distance > maxDistance {
+				farthest, maxDistance = i, distance
+			}
+		}
+
+		if farthest < 0 {
+			continue
+		}
+
+		keep[farthest] = true
+		stack = append(stack, [2]int{first, farthest}, [2]int{farthest, last})
+	}
+
+	simplified := make([]*entity.Coordinate, 0, len(coordinates))
+	for i, coordinate := range coordinates {
+		if keep[i] {
+			simplified = append(simplified, coordinate)
+		}
+	}
+
+	return simplified
+}
+
+// segmentDistance returns the distance, in meters, from p to the segment
+// between a and b. It projects the points on a plane around a, which is
+// accurate enough for the short segments of a track.
+func segmentDistance(p, a, b *entity.Coordinate) float64 {
+	scale := math.Cos(toRadians(a.Lat))
+	project := func(c *entity.Coordinate) (float64, float64) {
+		x := toRadians(c.Long-a.Long) * scale * EarthRadius
+		y := toRadians(c.Lat-a.Lat) * EarthRadius
+		return x, y
+	}
+
+	px, py := project(p)
+	bx, by := project(b)
+
+	length := bx*bx + by*by
+	if length == 0 {
+		return math.Hypot(px, py)
+	}
+
+	t := math.Max(0, math.Min(1, (px*bx+py*by)/length))
+	return math.Hypot(px-t*bx, py-t*by)
+}
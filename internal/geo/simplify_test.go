@@ -0,0 +1,75 @@
+package geo
+
+import (
+	"slices"
+	"testing"
+
+	"runmate_api/internal/entity"
+)
+
+// offset returns a coordinate meters north of the equator and east meters
+// east of the prime meridian.
+func offset(north, east float64) *entity.Coordinate {
+	return &entity.Coordinate{Lat: metersToDegrees(north), Long: metersToDegrees(east)}
+}
+
+func TestSimplify(t *testing.T) {
+	tests := []struct {
+		name        string
+		coordinates []*entity.Coordinate
+		tolerance   float64
+		kept        []int
+	}{
+		{
+			name:        "straight line keeps the ends",
+			coordinates: []*entity.Coordinate{offset(0, 0), offset(100, 0), offset(200, 0), offset(300, 0)},
+			tolerance:   5,
+			kept:        []int{0, 3},
+		},
+		{
+			name:        "drops points within tolerance",
+			coordinates: []*entity.Coordinate{offset(0, 0), offset(100, 3), offset(200, 0)},
+			tolerance:   5,
+			kept:        []int{0, 2},
+		},
+		{
+			name:        "keeps points beyond tolerance",
+			coordinates: []*entity.Coordinate{offset(0, 0), offset(100, 10), offset(200, 0)},
+			tolerance:   5,
+			kept:        []int{0, 1, 2},
+		},
+		{
+			name: "keeps the corners of a detour",
+			coordinates: []*entity.Coordinate{
+				offset(0, 0), offset(100, 1), offset(200, 0), offset(200, 100), offset(201, 200), offset(200, 300),
+			},
+			tolerance: 5,
+			kept:      []int{0, 2, 5},
+		},
+		{
+			name:        "out and back keeps the turn",
+			coordinates: []*entity.Coordinate{offset(0, 0), offset(500, 0), offset(1000, 0), offset(500, 0), offset(0, 0)},
+			tolerance:   5,
+			kept:        []int{0, 2, 4},
+		},
+		{
+			name:        "too short to simplify",
+			coordinates: []*entity.Coordinate{offset(0, 0), offset(100, 0)},
+			tolerance:   5,
+			kept:        []int{0, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var kept []int
+			for _, coordinate := range Simplify(tt.coordinates, tt.tolerance) {
+				kept = append(kept, slices.Index(tt.coordinates, coordinate))
+			}
+
+			if !slices.Equal(kept, tt.kept) {
+				t.Errorf("Simplify() kept %v, want %v", kept, tt.kept)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package geo
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"runmate_api/internal/entity"
+)
+
+const (
+	Kilometer = 1000.0
+	Mile      = 1609.344
+)
+
+var (
+	ErrNoTimestamps = errors.New("track has no timestamps")
+)
+
+type Split struct {
+	Number         int
+	Distance       float64
+	Duration       float64
+	Pace           float64
+	ElevationDelta *float64
+}
+
+type Splits struct {
+	Unit          float64
+	Splits        []*Split
+	Fastest       *Split
+	NegativeSplit bool
+}
+
+// profile is the track flattened into cumulative distance, elapsed seconds and
+// altitude per timestamped coordinate.
+type profile struct {
+	distances []float64
+	seconds   []float64
+	altitudes []*float64
+}
+
+func newProfile(coordinates []*entity.Coordinate) *profile {
+	p := &profile{}
+	var previous, first *entity.Coordinate
+	var distance float64
+	for _, coordinate := range coordinates {
+		if previous != nil {
+			distance += CoordinateDistance(previous, coordinate)
+		}
+		previous = coordinate
+
+		if coordinate.Time == nil {
+			continue
+		}
+
+		if first == nil {
+			first = coordinate
+		}
+
+		p.distances = append(p.distances, distance)
+		p.seconds = append(p.seconds, coordinate.Time.Sub(*first.Time).Seconds())
+		p.altitudes = append(p.altitudes, coordinate.Altitude)
+	}
+
+	return p
+}
+
+func (p *profile) total() float64 {
+	return p.distances[len(p.distances)-1]
+}
+
+// at interpolates the elapsed seconds and altitude at the given distance.
+func (p *profile) at(distance float64) (float64, *float64) {
+	i := sort.SearchFloat64s(p.distances, distance)
+	if i == 0 {
+		return p.seconds[0], p.altitudes[0]
+	}
+
+	if i >= len(p.distances) {
+		last := len(p.distances) - 1
+		return p.seconds[last], p.altitudes[last]
+	}
+
+	ratio := 0.0
+	if span := p.distances[i] - p.distances[i-1]; span > 0 {
+		ratio = (distance - p.distances[i-1]) / span
+	}
+
+	seconds := p.seconds[i-1] + ratio*(p.seconds[i]-p.seconds[i-1])
+	if p.altitudes[i-1] == nil || p.altitudes[i] == nil {
+		return seconds, nil
+	}
+
+	altitude := *p.altitudes[i-1] + ratio*(*p.altitudes[i]-*p.altitudes[i-1])
+	return seconds, &altitude
+}
+
+// ComputeSplits breaks the timestamped track into segments of unit meters. The
+// last split may be shorter than unit. Splits start at the first timestamped
+// point, as the time before it is unknown.
+func ComputeSplits(coordinates []*entity.Coordinate, unit float64) (*Splits, error) {
+	p := newProfile(coordinates)
+	if len(p.distances) < 2 {
+		return nil, ErrNoTimestamps
+	}
+
+	first, total := p.distances[0], p.total()
+	result := &Splits{Unit: unit}
+	startSeconds, startAltitude := p.at(first)
+	count := int(math.Ceil((total - first) / unit))
+	for i := 0; i < count; i++ {
+		start := first + float64(i)*unit
+		end := math.Min(start+unit, total)
+		endSeconds, endAltitude := p.at(end)
+		// Only the last split may be short.
+		full := i < count-1 || start+unit <= total
+
+		split := &Split{
+			Number:   len(result.Splits) + 1,
+			Distance: end - start,
+			Duration: endSeconds - startSeconds,
+		}
+		split.Pace = split.Duration / (split.Distance / unit)
+		if startAltitude != nil && endAltitude != nil {
+			delta := *endAltitude - *startAltitude
+			split.ElevationDelta = &delta
+		}
+
+		// A short trailing split is not comparable to full ones, and is only
+		// the fastest when it is the only split.
+		if result.Fastest == nil || (full && split.Pace < result.Fastest.Pace) {
+			result.Fastest = split
+		}
+
+		result.Splits = append(result.Splits, split)
+		startSeconds, startAltitude = endSeconds, endAltitude
+	}
+
+	halfSeconds, _ := p.at(first + (total-first)/2)
+	lastSeconds, _ := p.at(total)
+	result.NegativeSplit = lastSeconds-halfSeconds < halfSeconds
+
+	return result, nil
+}
@@ -0,0 +1,180 @@
+package geo
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"runmate_api/internal/entity"
+)
+
+var trackStart = time.Date(2024, 5, 1, 7, 0, 0, 0, time.UTC)
+
+// point is a position along a meridian, meters north of the equator, reached
+// seconds after the start. Negative seconds leave it without a timestamp.
+type point struct {
+	meters  float64
+	seconds float64
+}
+
+func metersToDegrees(meters float64) float64 {
+	return meters / (EarthRadius * math.Pi / 180)
+}
+
+func track(points ...point) []*entity.Coordinate {
+	coordinates := make([]*entity.Coordinate, 0, len(points))
+	for _, p := range points {
+		coordinate := &entity.Coordinate{Lat: metersToDegrees(p.meters)}
+		if p.seconds >= 0 {
+			t := trackStart.Add(time.Duration(p.seconds * float64(time.Second)))
+			coordinate.Time = &t
+		}
+
+		coordinates = append(coordinates, coordinate)
+	}
+
+	return coordinates
+}
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 0.01
+}
+
+func TestComputeSplits(t *testing.T) {
+	tests := []struct {
+		name          string
+		coordinates   []*entity.Coordinate
+		unit          float64
+		durations     []float64
+		distances     []float64
+		fastest       int
+		negativeSplit bool
+	}{
+		{
+			name:        "even pace",
+			coordinates: track(point{0, 0}, point{1000, 300}, point{2000, 600}),
+			unit:        Kilometer,
+			durations:   []float64{300, 300},
+			distances:   []float64{1000, 1000},
+			fastest:     1,
+		},
+		{
+			name:          "faster second half",
+			coordinates:   track(point{0, 0}, point{1000, 330}, point{2000, 600}, point{2100, 630}),
+			unit:          Kilometer,
+			durations:     []float64{330, 270, 30},
+			distances:     []float64{1000, 1000, 100},
+			fastest:       2,
+			negativeSplit: true,
+		},
+		{
+			name:          "short trailing split is not the fastest",
+			coordinates:   track(point{0, 0}, point{1000, 300}, point{2000, 610}, point{2200, 630}),
+			unit:          Kilometer,
+			durations:     []float64{300, 310, 20},
+			distances:     []float64{1000, 1000, 200},
+			fastest:       1,
+			negativeSplit: true,
+		},
+		{
+			name:        "interpolates between points",
+			coordinates: track(point{0, 0}, point{800, 240}, point{1600, 480}),
+			unit:        Kilometer,
+			durations:   []float64{300, 180},
+			distances:   []float64{1000, 600},
+			fastest:     1,
+		},
+		{
+			name:          "miles",
+			coordinates:   track(point{0, 0}, point{Mile, 420}, point{2 * Mile, 810}, point{2*Mile + 100, 840}),
+			unit:          Mile,
+			durations:     []float64{420, 390, 30},
+			distances:     []float64{Mile, Mile, 100},
+			fastest:       2,
+			negativeSplit: true,
+		},
+		{
+			name:        "untimed prefix is left out",
+			coordinates: track(point{0, -1}, point{500, -1}, point{500, 0}, point{1500, 300}, point{2500, 600}),
+			unit:        Kilometer,
+			durations:   []float64{300, 300},
+			distances:   []float64{1000, 1000},
+			fastest:     1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			splits, err := ComputeSplits(tt.coordinates, tt.unit)
+			if err != nil {
+				t.Fatalf("ComputeSplits() error = %v", err)
+			}
+
+			if len(splits.Splits) != len(tt.durations) {
+				t.Fatalf("got %d splits, want %d", len(splits.Splits), len(tt.durations))
+			}
+
+			for i, split := range splits.Splits {
+				if split.Number != i+1 {
+					t.Errorf("split %d: number = %d", i+1, split.Number)
+				}
+
+				if !almostEqual(split.Duration, tt.durations[i]) {
+					t.Errorf("split %d: duration = %v, want %v", i+1, split.Duration, tt.durations[i])
+				}
+
+				if !almostEqual(split.Distance, tt.distances[i]) {
+					t.Errorf("split %d: distance = %v, want %v", i+1, split.Distance, tt.distances[i])
+				}
+			}
+
+			if splits.Fastest.Number != tt.fastest {
+				t.Errorf("fastest = %d, want %d", splits.Fastest.Number, tt.fastest)
+			}
+
+			if splits.NegativeSplit != tt.negativeSplit {
+				t.Errorf("negative split = %v, want %v", splits.NegativeSplit, tt.negativeSplit)
+			}
+		})
+	}
+}
+
+func TestComputeSplitsElevation(t *testing.T) {
+	coordinates := track(point{0, 0}, point{1000, 300}, point{2000, 600})
+	for i, altitude := range []float64{10, 30, 25} {
+		coordinates[i].Altitude = &altitude
+	}
+
+	splits, err := ComputeSplits(coordinates, Kilometer)
+	if err != nil {
+		t.Fatalf("ComputeSplits() error = %v", err)
+	}
+
+	for i, want := range []float64{20, -5} {
+		delta := splits.Splits[i].ElevationDelta
+		if delta == nil || !almostEqual(*delta, want) {
+			t.Errorf("split %d: elevation delta = %v, want %v", i+1, delta, want)
+		}
+	}
+}
+
+func TestComputeSplitsWithoutTimestamps(t *testing.T) {
+	tests := []struct {
+		name        string
+		coordinates []*entity.Coordinate
+	}{
+		{name: "empty"},
+		{name: "untimed", coordinates: track(point{0, -1}, point{1000, -1})},
+		{name: "single timestamp", coordinates: track(point{0, 0}, point{1000, -1})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ComputeSplits(tt.coordinates, Kilometer)
+			if !errors.Is(err, ErrNoTimestamps) {
+				t.Errorf("ComputeSplits() error = %v, want %v", err, ErrNoTimestamps)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package geo
+
+import (
+	"math"
+
+	"runmate_api/internal/entity"
+)
+
+// minMovingSpeed is the speed, in meters per second, under which the runner is
+// considered stopped.
+const minMovingSpeed = 0.5
+
+type Summary struct {
+	Distance      float64
+	ElevationGain float64
+	ElapsedTime   int
+	MovingTime    int
+	HasTime       bool
+}
+
+func (s *Summary) Pace() float64 {
+	return Pace(s.Distance, s.MovingTime)
+}
+
+// Summarize computes the distance along the ordered coordinates and, when they
+// are timestamped, the elapsed and moving times in seconds. The elevation gain
+// sums the climbs between consecutive coordinates with altitude.
+func Summarize(coordinates []*entity.Coordinate) *Summary {
+	summary := &Summary{}
+	var first, previousTimed, previousAltitude *entity.Coordinate
+	var movingTime, distanceSinceTimed float64
+	for i, coordinate := range coordinates {
+		if i > 0 {
+			distance := CoordinateDistance(coordinates[i-1], coordinate)
+			summary.Distance += distance
+			distanceSinceTimed += distance
+		}
+
+		if coordinate.Altitude != nil {
+			if previousAltitude != nil && *coordinate.Altitude > *previousAltitude.Altitude {
+				summary.ElevationGain += *coordinate.Altitude - *previousAltitude.Altitude
+			}
+
+			previousAltitude = coordinate
+		}
+
+		if coordinate.Time == nil {
+			continue
+		}
+
+		if first == nil {
+			first = coordinate
+		}
+
+		if previousTimed != nil {
+			seconds := coordinate.Time.Sub(*previousTimed.Time).Seconds()
+			if seconds > 0 && distanceSinceTimed/seconds >= minMovingSpeed {
+				movingTime += seconds
+			}
+		}
+
+		previousTimed = coordinate
+		distanceSinceTimed = 0
+	}
+
+	if first != nil && previousTimed != first {
+		summary.HasTime = true
+		summary.ElapsedTime = int(math.Round(previousTimed.Time.Sub(*first.Time).Seconds()))
+		summary.MovingTime = int(math.Round(movingTime))
+	}
+
+	return summary
+}
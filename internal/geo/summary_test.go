@@ -0,0 +1,91 @@
+package geo
+
+import "testing"
+
+func TestSummarize(t *testing.T) {
+	tests := []struct {
+		name        string
+		points      []point
+		altitudes   []float64
+		distance    float64
+		elevation   float64
+		elapsedTime int
+		movingTime  int
+		hasTime     bool
+	}{
+		{
+			name:        "steady",
+			points:      []point{{0, 0}, {1000, 300}, {2000, 600}},
+			distance:    2000,
+			elapsedTime: 600,
+			movingTime:  600,
+			hasTime:     true,
+		},
+		{
+			name:        "stop is not moving time",
+			points:      []point{{0, 0}, {1000, 300}, {1000, 600}, {2000, 900}},
+			distance:    2000,
+			elapsedTime: 900,
+			movingTime:  600,
+			hasTime:     true,
+		},
+		{
+			name:        "untimed point in between",
+			points:      []point{{0, 0}, {500, -1}, {1000, 300}},
+			distance:    1000,
+			elapsedTime: 300,
+			movingTime:  300,
+			hasTime:     true,
+		},
+		{
+			name:      "climbs only",
+			points:    []point{{0, -1}, {100, -1}, {200, -1}, {300, -1}},
+			altitudes: []float64{10, 30, 25, 40},
+			distance:  300,
+			elevation: 35,
+		},
+		{
+			name:     "untimed",
+			points:   []point{{0, -1}, {1000, -1}},
+			distance: 1000,
+		},
+		{
+			name:     "single timestamp",
+			points:   []point{{0, -1}, {1000, 300}},
+			distance: 1000,
+		},
+		{
+			name: "empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			coordinates := track(tt.points...)
+			for i, altitude := range tt.altitudes {
+				coordinates[i].Altitude = &altitude
+			}
+
+			summary := Summarize(coordinates)
+			if !almostEqual(summary.Distance, tt.distance) {
+				t.Errorf("distance = %v, want %v", summary.Distance, tt.distance)
+			}
+
+			if !almostEqual(summary.ElevationGain, tt.elevation) {
+				t.Errorf("elevation gain = %v, want %v", summary.ElevationGain, tt.elevation)
+			}
+
+			if summary.ElapsedTime != tt.elapsedTime {
+				t.Errorf("elapsed time = %v, want %v", summary.ElapsedTime, tt.elapsedTime)
+			}
+
+			if summary.MovingTime != tt.movingTime {
+				t.Errorf("moving time = %v, want %v", summary.MovingTime, tt.movingTime)
+			}
+
+			if summary.HasTime != tt.hasTime {
+				t.Errorf("has time = %v, want %v", summary.HasTime, tt.hasTime)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"net/http"
+
+	_ "image/gif"
+	_ "image/png"
+)
+
+const (
+	MaxSize = 10 << 20
+	// MaxPixels guards against images that are small files but huge once
+	// decoded.
+	MaxPixels     = 4096 * 4096
+	ThumbnailSize = 320
+
+	thumbnailQuality = 80
+	// thumbnailSamples is how many source pixels per axis are averaged into
+	// each thumbnail pixel.
+	thumbnailSamples = 4
+)
+
+var (
+	ErrTooLarge        = errors.New("image is too large")
+	ErrUnsupportedType = errors.New("image type is not supported, use JPEG, PNG or GIF")
+)
+
+var extensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// Image is an uploaded image checked by Process, along with its thumbnail.
+type Image struct {
+	ContentType string
+	Extension   string
+	Width       int
+	Height      int
+	Data        []byte
+	// Thumbnail is a JPEG fitting a ThumbnailSize square.
+	Thumbnail []byte
+}
+
+// Process checks the image type, from its content rather than the name or
+// the header sent by the client, and its size, and generates the thumbnail.
+func Process(data []byte) (*Image, error) {
+	if len(data) > MaxSize {
+		return nil, ErrTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	extension, ok := extensions[contentType]
+	if !ok {
+		return nil, ErrUnsupportedType
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedType
+	}
+
+	if config.Width*config.Height > MaxPixels {
+		return nil, ErrTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedType
+	}
+
+	var thumbnail bytes.Buffer
+	err = jpeg.Encode(&thumbnail, Thumbnail(img, ThumbnailSize), &jpeg.Options{Quality: thumbnailQuality})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode thumbnail: %v", err)
+	}
+
+	return &Image{
+		ContentType: contentType,
+		Extension:   extension,
+		Width:       config.Width,
+		Height:      config.Height,
+		Data:        data,
+		Thumbnail:   thumbnail.Bytes(),
+	}, nil
+}
+
+// Thumbnail scales the image down, keeping its aspect ratio, to fit a size
+// square. Each thumbnail pixel is the average of up to thumbnailSamples²
+// pixels evenly spread over the ones it covers, read from img without copying
+// it.
+func Thumbnail(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= size && height <= size {
+		size = max(width, height)
+	}
+
+	thumbWidth, thumbHeight := size, size
+	if width > height {
+		thumbHeight = max(height*size/width, 1)
+	} else {
+		thumbWidth = max(width*size/height, 1)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, thumbWidth, thumbHeight))
+	for y := range thumbHeight {
+		y0, y1 := y*height/thumbHeight, max((y+1)*height/thumbHeight, y*height/thumbHeight+1)
+		stepY := max((y1-y0)/thumbnailSamples, 1)
+		for x := range thumbWidth {
+			x0, x1 := x*width/thumbWidth, max((x+1)*width/thumbWidth, x*width/thumbWidth+1)
+			stepX := max((x1-x0)/thumbnailSamples, 1)
+
+			var r, g, b, a, n uint32
+			for sy := y0; sy < y1; sy += stepY {
+				for sx := x0; sx < x1; sx += stepX {
+					pr, pg, pb, pa := img.At(bounds.Min.X+sx, bounds.Min.Y+sy).RGBA()
+					r, g, b, a = r+pr>>8, g+pg>>8, b+pb>>8, a+pa>>8
+					n++
+				}
+			}
+
+			i := dst.PixOffset(x, y)
+			dst.Pix[i], dst.Pix[i+1], dst.Pix[i+2], dst.Pix[i+3] = uint8(r/n), uint8(g/n), uint8(b/n), uint8(a/n)
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,132 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+
+	var b bytes.Buffer
+	err := png.Encode(&b, img)
+	if err != nil {
+		t.Fatalf("png.Encode() error = %v", err)
+	}
+
+	return b.Bytes()
+}
+
+func uniform(width, height int, c color.Color) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			img.Set(x, y, c)
+		}
+	}
+
+	return img
+}
+
+func TestThumbnailSize(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height int
+		wantWidth     int
+		wantHeight    int
+	}{
+		{name: "landscape", width: 640, height: 480, wantWidth: 320, wantHeight: 240},
+		{name: "portrait", width: 480, height: 640, wantWidth: 240, wantHeight: 320},
+		{name: "square", width: 1000, height: 1000, wantWidth: 320, wantHeight: 320},
+		{name: "smaller than the thumbnail", width: 100, height: 50, wantWidth: 100, wantHeight: 50},
+		{name: "thin strip", width: 2000, height: 1, wantWidth: 320, wantHeight: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := image.NewGray(image.Rect(0, 0, tt.width, tt.height))
+			bounds := Thumbnail(img, ThumbnailSize).Bounds()
+			if bounds.Dx() != tt.wantWidth || bounds.Dy() != tt.wantHeight {
+				t.Errorf("Thumbnail() is %dx%d, want %dx%d", bounds.Dx(), bounds.Dy(), tt.wantWidth, tt.wantHeight)
+			}
+		})
+	}
+}
+
+func TestThumbnailColors(t *testing.T) {
+	red := color.RGBA{R: 200, G: 10, B: 10, A: 255}
+	if got := Thumbnail(uniform(640, 480, red), ThumbnailSize).At(100, 100); got != red {
+		t.Errorf("Thumbnail() of a uniform image has %v, want %v", got, red)
+	}
+
+	// Columns alternating between black and white average to gray.
+	stripes := image.NewGray(image.Rect(0, 0, 640, 640))
+	for y := range 640 {
+		for x := 0; x < 640; x += 2 {
+			stripes.SetGray(x, y, color.Gray{Y: 255})
+		}
+	}
+
+	gray := Thumbnail(stripes, ThumbnailSize).At(10, 10).(color.RGBA)
+	if gray.R != 127 || gray.G != 127 || gray.B != 127 {
+		t.Errorf("Thumbnail() of stripes has %v, want gray", gray)
+	}
+
+	// The thumbnail is read from the bounds of the image, wherever they start.
+	sub := uniform(200, 200, red).(*image.RGBA).SubImage(image.Rect(50, 50, 150, 150))
+	if got := Thumbnail(sub, ThumbnailSize).At(0, 0); got != red {
+		t.Errorf("Thumbnail() of a sub image has %v, want %v", got, red)
+	}
+}
+
+func TestProcess(t *testing.T) {
+	data := encodePNG(t, uniform(640, 480, color.White))
+	img, err := Process(data)
+	if err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+
+	if img.ContentType != "image/png" || img.Extension != ".png" {
+		t.Errorf("Process() type = %s %s, want image/png .png", img.ContentType, img.Extension)
+	}
+
+	if img.Width != 640 || img.Height != 480 {
+		t.Errorf("Process() is %dx%d, want 640x480", img.Width, img.Height)
+	}
+
+	thumbnail, err := jpeg.Decode(bytes.NewReader(img.Thumbnail))
+	if err != nil {
+		t.Fatalf("thumbnail is not a JPEG: %v", err)
+	}
+
+	if bounds := thumbnail.Bounds(); bounds.Dx() != 320 || bounds.Dy() != 240 {
+		t.Errorf("thumbnail is %dx%d, want 320x240", bounds.Dx(), bounds.Dy())
+	}
+}
+
+func TestProcessRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{name: "larger than the maximum size", data: make([]byte, MaxSize+1), want: ErrTooLarge},
+		{name: "more pixels than the maximum", data: encodePNG(t, image.NewGray(image.Rect(0, 0, 8193, 2049))), want: ErrTooLarge},
+		{name: "not an image", data: []byte("%PDF-1.4"), want: ErrUnsupportedType},
+		{name: "truncated image", data: encodePNG(t, image.NewGray(image.Rect(0, 0, 10, 10)))[:40], want: ErrUnsupportedType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Process(tt.data)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Process() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package outbox
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"runmate_api/internal/entity"
+	"runmate_api/internal/firebase"
+	"runmate_api/internal/repository"
+)
+
+const (
+	pollInterval = 5 * time.Second
+	batchSize    = 50
+	maxAttempts  = 10
+
+	// Claimed notifications are kept from other dispatchers for leaseDuration,
+	// longer than sending a batch takes.
+	leaseDuration = 5 * time.Minute
+
+	// Retries wait baseBackoff, doubled after each failed attempt up to maxBackoff.
+	baseBackoff = 30 * time.Second
+	maxBackoff  = time.Hour
+)
+
+func backoff(attempts int) time.Duration {
+	delay := baseBackoff
+	for i := 1; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+
+	return min(delay, maxBackoff)
+}
+
+type Dispatcher struct {
+	outboxRepo *repository.Outbox
+
+	firebaseClient *firebase.Client
+}
+
+func NewDispatcher(outboxRepo *repository.Outbox, firebaseClient *firebase.Client) *Dispatcher {
+	return &Dispatcher{
+		outboxRepo: outboxRepo,
+
+		firebaseClient: firebaseClient,
+	}
+}
+
+// Start delivers due notifications every pollInterval until ctx is done.
+func (d *Dispatcher) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				err := d.dispatch(ctx)
+				if err != nil {
+					log.Println("Failed to dispatch outbox notifications:", err)
+				}
+			}
+		}
+	}()
+}
+
+// dispatch claims a batch of due notifications and sends them, recording the
+// result of each one as soon as it is known, so a failure to record one never
+// sends the others again.
+func (d *Dispatcher) dispatch(ctx context.Context) error {
+	notifications, err := d.outboxRepo.Claim(ctx, batchSize, time.Now().Add(leaseDuration))
+	if err != nil {
+		return err
+	}
+
+	for _, notification := range notifications {
+		d.deliver(ctx, notification)
+
+		err = d.outboxRepo.Update(ctx, notification)
+		if err != nil {
+			log.Printf("Failed to record outbox notification %s: %v\n", notification.ID, err)
+		}
+	}
+
+	return nil
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, notification *entity.OutboxNotification) {
+	now := time.Now()
+	notification.Attempts++
+
+	err := d.firebaseClient.SendNotification(ctx, &firebase.Notification{
+		Title: notification.Title,
+		Body:  notification.Body,
+	}, []string{notification.Token})
+	if err == nil {
+		notification.SentAt = &now
+		notification.LastError = ""
+		return
+	}
+
+	notification.LastError = err.Error()
+	if notification.Attempts >= maxAttempts {
+		notification.FailedAt = &now
+		log.Printf("Giving up on outbox notification %s: %v\n", notification.ID, err)
+		return
+	}
+
+	notification.NextAttemptAt = now.Add(backoff(notification.Attempts))
+}
@@ -0,0 +1,50 @@
+package reminder
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"runmate_api/internal/service"
+)
+
+const checkInterval = 10 * time.Minute
+
+// Scheduler periodically looks for users to remind, each in their own time
+// zone. The services record the reminders sent, so checks may repeat.
+type Scheduler struct {
+	goalService   *service.Goal
+	streakService *service.Streak
+}
+
+func NewScheduler(goalService *service.Goal, streakService *service.Streak) *Scheduler {
+	return &Scheduler{
+		goalService:   goalService,
+		streakService: streakService,
+	}
+}
+
+// Start checks for reminders every checkInterval until ctx is done.
+func (s *Scheduler) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(checkInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				err := s.goalService.WarnAtRisk(ctx, now)
+				if err != nil {
+					log.Println("Failed to warn users about their goals:", err)
+				}
+
+				err = s.streakService.Check(ctx, now)
+				if err != nil {
+					log.Println("Failed to check streaks:", err)
+				}
+			}
+		}
+	}()
+}
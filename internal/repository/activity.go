@@ -2,15 +2,118 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 
 	"runmate_api/internal/entity"
+	"runmate_api/internal/geo"
 )
 
+// visibleTo keeps the activities the viewer may see: their own, public ones
+// and friends-only ones of users who added the viewer as a friend.
+func visibleTo(viewerID uuid.UUID) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(
+			"activities.user_id = ? OR activities.visibility = ? OR (activities.visibility = ? AND EXISTS (SELECT 1 FROM user_friends WHERE user_friends.user_id = activities.user_id AND user_friends.friend_id = ?))",
+			viewerID, entity.ActivityVisibilityPublic, entity.ActivityVisibilityFriends, viewerID,
+		)
+	}
+}
+
+// preloadVisibleCoordinates leaves out, for anyone but the owner, the
+// coordinates inside the owner's privacy zones.
+func preloadVisibleCoordinates(viewerID uuid.UUID) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Preload("Coordinates", func(db *gorm.DB) *gorm.DB {
+			return db.
+				Where(`NOT EXISTS (
+					SELECT 1 FROM activities
+					JOIN privacy_zones ON privacy_zones.user_id = activities.user_id
+					WHERE activities.id = coordinates.activity_id AND activities.user_id <> ?
+					AND 2 * ? * ASIN(LEAST(1, SQRT(
+						POWER(SIN(RADIANS(coordinates.lat - privacy_zones.lat) / 2), 2) +
+						COS(RADIANS(privacy_zones.lat)) * COS(RADIANS(coordinates.lat)) *
+						POWER(SIN(RADIANS(coordinates.long - privacy_zones.long) / 2), 2)
+					))) <= privacy_zones.radius
+				)`, viewerID, geo.EarthRadius).
+				Order("coordinates.order ASC")
+		})
+	}
+}
+
+// filtered applies the filter and the listing order. Pages are read with one
+// activity more than the limit, telling whether another page follows.
+func filtered(filter *entity.ActivityFilter) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Order("activities.date DESC, activities.id DESC")
+		if filter == nil {
+			return db
+		}
+
+		if len(filter.Types) > 0 {
+			db = db.Where("activities.type IN ?", filter.Types)
+		}
+
+		if filter.From != nil {
+			db = db.Where("activities.date >= ?", *filter.From)
+		}
+
+		if filter.To != nil {
+			db = db.Where("activities.date < ?", *filter.To)
+		}
+
+		if filter.After != nil {
+			db = db.Where("(activities.date, activities.id) < (?, ?)", filter.After.Date, filter.After.ID)
+		}
+
+		if filter.Limit > 0 {
+			db = db.Limit(filter.Limit + 1)
+		}
+
+		return db
+	}
+}
+
+// preloadTrack preloads the coordinates the viewer may see, unless the filter
+// leaves them out.
+func preloadTrack(viewerID uuid.UUID, filter *entity.ActivityFilter) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if filter != nil && filter.WithoutCoordinates {
+			return db
+		}
+
+		return preloadVisibleCoordinates(viewerID)(db)
+	}
+}
+
+// preloadMedia loads the photos of the activities in upload order.
+func preloadMedia(db *gorm.DB) *gorm.DB {
+	return db.Preload("Media", func(db *gorm.DB) *gorm.DB {
+		return db.Order("activity_media.created_at ASC")
+	})
+}
+
+// preloadRecords loads the personal records set by the activities.
+func preloadRecords(db *gorm.DB) *gorm.DB {
+	return db.Preload("BestEfforts", func(db *gorm.DB) *gorm.DB {
+		return db.Where("best_efforts.personal_record").Order("best_efforts.kind ASC")
+	})
+}
+
+// withCounts selects the number of kudos and comments of each activity.
+func withCounts(db *gorm.DB) *gorm.DB {
+	return db.Select(
+		"activities.*, " +
+			"(SELECT COUNT(*) FROM kudos WHERE kudos.activity_id = activities.id) AS kudos_count, " +
+			"(SELECT COUNT(*) FROM comments WHERE comments.activity_id = activities.id) AS comment_count",
+	)
+}
+
 type Activity struct {
 	db *gorm.DB
 }
@@ -20,7 +123,7 @@ func NewActivity(db *gorm.DB) *Activity {
 }
 
 func (a *Activity) Create(ctx context.Context, activity *entity.Activity) error {
-	result := a.db.WithContext(ctx).Create(activity)
+	result := conn(ctx, a.db).Create(activity)
 	if result.Error != nil {
 		return fmt.Errorf("failed to create activity: %v", result.Error)
 	}
@@ -28,13 +131,71 @@ func (a *Activity) Create(ctx context.Context, activity *entity.Activity) error
 	return nil
 }
 
-func (a *Activity) GetAll(ctx context.Context) ([]*entity.Activity, error) {
-	var activities []*entity.Activity
-	result := a.db.
-		WithContext(ctx).
+func (a *Activity) GetByID(ctx context.Context, id string) (*entity.Activity, error) {
+	var activity entity.Activity
+	result := conn(ctx, a.db).
 		Preload("Coordinates", func(db *gorm.DB) *gorm.DB {
 			return db.Order("coordinates.order ASC")
 		}).
+		Scopes(preloadMedia).
+		Preload("User").
+		Where("id = ?", id).
+		First(&activity)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get activity %s: %v", id, result.Error)
+	}
+
+	return &activity, nil
+}
+
+// GetByIDForUpdate locks the activity until the end of the transaction, so that
+// its review is decided only once.
+func (a *Activity) GetByIDForUpdate(ctx context.Context, id string) (*entity.Activity, error) {
+	var activity entity.Activity
+	result := conn(ctx, a.db).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("Coordinates", func(db *gorm.DB) *gorm.DB {
+			return db.Order("coordinates.order ASC")
+		}).
+		Where("id = ?", id).
+		First(&activity)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get activity %s: %v", id, result.Error)
+	}
+
+	return &activity, nil
+}
+
+func (a *Activity) GetVisibleByID(ctx context.Context, id string, viewerID uuid.UUID) (*entity.Activity, error) {
+	var activity entity.Activity
+	result := conn(ctx, a.db).
+		Scopes(withCounts, visibleTo(viewerID), preloadVisibleCoordinates(viewerID), preloadMedia, preloadRecords).
+		Preload("User").
+		Where("id = ?", id).
+		First(&activity)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get activity %s: %v", id, result.Error)
+	}
+
+	return &activity, nil
+}
+
+func (a *Activity) GetAll(ctx context.Context, viewerID uuid.UUID, filter *entity.ActivityFilter) ([]*entity.Activity, error) {
+	var activities []*entity.Activity
+	result := conn(ctx, a.db).
+		Scopes(withCounts, visibleTo(viewerID), filtered(filter), preloadTrack(viewerID, filter), preloadMedia, preloadRecords).
 		Preload("User").
 		Find(&activities)
 	if result.Error != nil {
@@ -44,13 +205,10 @@ func (a *Activity) GetAll(ctx context.Context) ([]*entity.Activity, error) {
 	return activities, nil
 }
 
-func (a *Activity) GetByUserID(ctx context.Context, userID string) ([]*entity.Activity, error) {
+func (a *Activity) GetByUserID(ctx context.Context, userID string, viewerID uuid.UUID, filter *entity.ActivityFilter) ([]*entity.Activity, error) {
 	var activities []*entity.Activity
-	result := a.db.
-		WithContext(ctx).
-		Preload("Coordinates", func(db *gorm.DB) *gorm.DB {
-			return db.Order("coordinates.order ASC")
-		}).
+	result := conn(ctx, a.db).
+		Scopes(withCounts, visibleTo(viewerID), filtered(filter), preloadTrack(viewerID, filter), preloadMedia, preloadRecords).
 		Preload("User").
 		Where("user_id = ?", userID).
 		Find(&activities)
@@ -61,10 +219,33 @@ func (a *Activity) GetByUserID(ctx context.Context, userID string) ([]*entity.Ac
 	return activities, nil
 }
 
+// GetFeed lists the activities of the users the given user added as friends
+// and, optionally, their own.
+func (a *Activity) GetFeed(ctx context.Context, userID string, includeOwn bool, viewerID uuid.UUID, filter *entity.ActivityFilter) ([]*entity.Activity, error) {
+	db := conn(ctx, a.db).
+		Scopes(withCounts).
+		Joins("LEFT JOIN user_friends AS feed_friends ON feed_friends.friend_id = activities.user_id AND feed_friends.user_id = ?", userID)
+	if includeOwn {
+		db = db.Where("feed_friends.user_id IS NOT NULL OR activities.user_id = ?", userID)
+	} else {
+		db = db.Where("feed_friends.user_id IS NOT NULL")
+	}
+
+	var activities []*entity.Activity
+	result := db.
+		Scopes(visibleTo(viewerID), filtered(filter), preloadTrack(viewerID, filter), preloadMedia, preloadRecords).
+		Preload("User").
+		Find(&activities)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get feed for user %s: %v", userID, result.Error)
+	}
+
+	return activities, nil
+}
+
 func (a *Activity) GetByUserIDAndDateRange(ctx context.Context, userID string, start, end time.Time) ([]*entity.Activity, error) {
 	var activities []*entity.Activity
-	result := a.db.
-		WithContext(ctx).
+	result := conn(ctx, a.db).
 		Preload("Coordinates", func(db *gorm.DB) *gorm.DB {
 			return db.Order("coordinates.order ASC")
 		}).
@@ -78,8 +259,157 @@ func (a *Activity) GetByUserIDAndDateRange(ctx context.Context, userID string, s
 	return activities, nil
 }
 
+// GetTotals sums the approved activities of the user the viewer may see,
+// grouped by the filter period in the filter time zone, oldest first.
+func (a *Activity) GetTotals(ctx context.Context, userID string, viewerID uuid.UUID, filter *entity.TotalsFilter) ([]*entity.ActivityTotals, error) {
+	sums := "COUNT(*) AS count, COALESCE(SUM(activities.distance), 0) AS distance, COALESCE(SUM(activities.duration), 0) AS duration, COALESCE(SUM(activities.elevation_gain), 0) AS elevation_gain"
+	db := conn(ctx, a.db).
+		Model(&entity.Activity{}).
+		Scopes(visibleTo(viewerID)).
+		Where("activities.user_id = ? AND activities.review_status = ?", userID, entity.ActivityReviewStatusApproved)
+
+	location := filter.Location
+	if location == nil {
+		location = time.UTC
+	}
+
+	switch filter.Period {
+	case entity.TotalsPeriodAll:
+		db = db.Select(sums)
+	case entity.TotalsPeriodWeek:
+		// date_trunc weeks start on Monday, so other week starts are shifted
+		// into place and back.
+		shift := (int(time.Monday) - int(filter.WeekStart) + 7) % 7
+		db = db.
+			Select("date_trunc('week', (activities.date AT TIME ZONE ?) + make_interval(days => ?)) - make_interval(days => ?) AS start, "+sums, location.String(), shift, shift).
+			Group("start").
+			Order("start ASC")
+	default:
+		db = db.
+			Select("date_trunc(?, activities.date AT TIME ZONE ?) AS start, "+sums, string(filter.Period), location.String()).
+			Group("start").
+			Order("start ASC")
+	}
+
+	if filter.From != nil {
+		db = db.Where("activities.date >= ?", *filter.From)
+	}
+
+	if len(filter.Types) > 0 {
+		db = db.Where("activities.type IN ?", filter.Types)
+	}
+
+	var totals []*entity.ActivityTotals
+	result := db.Scan(&totals)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get activity totals for user %s: %v", userID, result.Error)
+	}
+
+	// Periods start at local midnights, read back without a time zone.
+	for _, t := range totals {
+		t.Start = time.Date(t.Start.Year(), t.Start.Month(), t.Start.Day(), 0, 0, 0, 0, location)
+	}
+
+	return totals, nil
+}
+
+func (a *Activity) GetByIdempotencyKey(ctx context.Context, userID, key string) (*entity.Activity, error) {
+	var activity entity.Activity
+	result := conn(ctx, a.db).
+		Preload("Coordinates", func(db *gorm.DB) *gorm.DB {
+			return db.Order("coordinates.order ASC")
+		}).
+		Preload("User").
+		Where("user_id = ? AND idempotency_key = ?", userID, key).
+		First(&activity)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get activity for user %s by idempotency key %s: %v", userID, key, result.Error)
+	}
+
+	return &activity, nil
+}
+
+func (a *Activity) GetByUserIDOverlapping(ctx context.Context, userID string, start, end time.Time) ([]*entity.Activity, error) {
+	var activities []*entity.Activity
+	result := conn(ctx, a.db).
+		Preload("Coordinates", func(db *gorm.DB) *gorm.DB {
+			return db.Order("coordinates.order ASC")
+		}).
+		Preload("User").
+		Where("user_id = ? AND date <= ? AND date + duration * INTERVAL '1 second' >= ?", userID, end, start).
+		Order("date ASC").
+		Find(&activities)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get activities for user %s overlapping %s - %s: %v", userID, start, end, result.Error)
+	}
+
+	return activities, nil
+}
+
+func (a *Activity) GetByReviewStatus(ctx context.Context, status entity.ActivityReviewStatus) ([]*entity.Activity, error) {
+	var activities []*entity.Activity
+	result := conn(ctx, a.db).
+		Preload("User").
+		Where("review_status = ?", status).
+		Order("date ASC").
+		Find(&activities)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get activities with review status %d: %v", status, result.Error)
+	}
+
+	return activities, nil
+}
+
+func (a *Activity) GetByTrackHash(ctx context.Context, trackHash string, excludeID uuid.UUID) (*entity.Activity, error) {
+	var activity entity.Activity
+	result := conn(ctx, a.db).Where("track_hash = ? AND id <> ?", trackHash, excludeID).Order("date ASC").First(&activity)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get activity by track hash %s: %v", trackHash, result.Error)
+	}
+
+	return &activity, nil
+}
+
+func (a *Activity) Update(ctx context.Context, activity *entity.Activity) error {
+	result := conn(ctx, a.db).Omit(clause.Associations).Save(activity)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update activity %s: %v", activity.ID, result.Error)
+	}
+
+	return nil
+}
+
+func (a *Activity) ReplaceCoordinates(ctx context.Context, activity *entity.Activity, coordinates []*entity.Coordinate) error {
+	result := conn(ctx, a.db).Where("activity_id = ?", activity.ID).Delete(&entity.Coordinate{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete coordinates of activity %s: %v", activity.ID, result.Error)
+	}
+
+	for _, coordinate := range coordinates {
+		coordinate.ActivityID = activity.ID
+	}
+
+	if len(coordinates) > 0 {
+		result = conn(ctx, a.db).Create(coordinates)
+		if result.Error != nil {
+			return fmt.Errorf("failed to create coordinates of activity %s: %v", activity.ID, result.Error)
+		}
+	}
+
+	activity.Coordinates = coordinates
+	return nil
+}
+
 func (a *Activity) Delete(ctx context.Context, id string) error {
-	result := a.db.WithContext(ctx).Select(clause.Associations).Where("id = ?", id).Delete(&entity.Activity{})
+	result := conn(ctx, a.db).Select(clause.Associations).Where("id = ?", id).Delete(&entity.Activity{})
 	if result.Error != nil {
 		return fmt.Errorf("failed to delete activity %s: %v", id, result.Error)
 	}
@@ -18,7 +18,7 @@ func NewChallenge(db *gorm.DB) *Challenge {
 }
 
 func (c *Challenge) Create(ctx context.Context, challenge *entity.Challenge) error {
-	result := c.db.WithContext(ctx).Create(challenge)
+	result := conn(ctx, c.db).Create(challenge)
 	if result.Error != nil {
 		return fmt.Errorf("failed to create challenge: %v", result.Error)
 	}
@@ -28,7 +28,7 @@ func (c *Challenge) Create(ctx context.Context, challenge *entity.Challenge) err
 
 func (c *Challenge) GetByID(ctx context.Context, id string) (*entity.Challenge, error) {
 	var challenge entity.Challenge
-	result := c.db.WithContext(ctx).Preload("Users").Where("id = ?", id).First(&challenge)
+	result := conn(ctx, c.db).Preload("Users").Where("id = ?", id).First(&challenge)
 	if result.Error != nil {
 		return nil, fmt.Errorf("failed to get challenge %s: %v", id, result.Error)
 	}
@@ -38,7 +38,7 @@ func (c *Challenge) GetByID(ctx context.Context, id string) (*entity.Challenge,
 
 func (c *Challenge) GetAllActive(ctx context.Context) ([]*entity.Challenge, error) {
 	var challenges []*entity.Challenge
-	result := c.db.WithContext(ctx).Preload("Users").Where("end_date IS NULL OR end_date > NOW()").Find(&challenges)
+	result := conn(ctx, c.db).Preload("Users").Where("end_date IS NULL OR end_date > NOW()").Find(&challenges)
 	if result.Error != nil {
 		return nil, fmt.Errorf("failed to get active challenges: %v", result.Error)
 	}
@@ -47,7 +47,7 @@ func (c *Challenge) GetAllActive(ctx context.Context) ([]*entity.Challenge, erro
 }
 
 func (c *Challenge) Update(ctx context.Context, challenge *entity.Challenge) error {
-	result := c.db.WithContext(ctx).Save(challenge)
+	result := conn(ctx, c.db).Save(challenge)
 	if result.Error != nil {
 		return fmt.Errorf("failed to update challenge: %v", result.Error)
 	}
@@ -57,7 +57,7 @@ func (c *Challenge) Update(ctx context.Context, challenge *entity.Challenge) err
 
 func (c *Challenge) GetAllActiveWithoutUser(ctx context.Context, user *entity.User) ([]*entity.Challenge, error) {
 	var challenges []*entity.Challenge
-	err := c.db.WithContext(ctx).
+	err := conn(ctx, c.db).
 		Preload("Users").
 		Table("challenges").
 		Select("challenges.*").
@@ -74,7 +74,7 @@ func (c *Challenge) GetAllActiveWithoutUser(ctx context.Context, user *entity.Us
 
 func (c *Challenge) GetAllActiveByUser(ctx context.Context, user *entity.User) ([]*entity.Challenge, error) {
 	var challenges []*entity.Challenge
-	err := c.db.WithContext(ctx).Model(&user).Where("end_date IS NULL OR end_date > NOW()").Preload("Users").Association("Challenges").Find(&challenges)
+	err := conn(ctx, c.db).Model(&user).Where("end_date IS NULL OR end_date > NOW()").Preload("Users").Association("Challenges").Find(&challenges)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get challenges: %v", err)
 	}
@@ -84,7 +84,7 @@ func (c *Challenge) GetAllActiveByUser(ctx context.Context, user *entity.User) (
 
 func (c *Challenge) GetAllByUser(ctx context.Context, user *entity.User) ([]*entity.Challenge, error) {
 	var challenges []*entity.Challenge
-	err := c.db.WithContext(ctx).Model(&user).Preload("Users").Association("Challenges").Find(&challenges)
+	err := conn(ctx, c.db).Model(&user).Preload("Users").Association("Challenges").Find(&challenges)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get challenges: %v", err)
 	}
@@ -93,7 +93,7 @@ func (c *Challenge) GetAllByUser(ctx context.Context, user *entity.User) ([]*ent
 }
 
 func (c *Challenge) AddEvent(ctx context.Context, challenge *entity.Challenge, event *entity.ChallengeEvent) error {
-	err := c.db.WithContext(ctx).Model(&challenge).Association("Events").Append(event)
+	err := conn(ctx, c.db).Model(&challenge).Association("Events").Append(event)
 	if err != nil {
 		return fmt.Errorf("failed to add event to challenge: %v", err)
 	}
@@ -103,7 +103,7 @@ func (c *Challenge) AddEvent(ctx context.Context, challenge *entity.Challenge, e
 
 func (c *Challenge) GetAllEventsByUser(ctx context.Context, challenge *entity.Challenge, user *entity.User) ([]*entity.ChallengeEvent, error) {
 	var events []*entity.ChallengeEvent
-	err := c.db.WithContext(ctx).Model(&challenge).Association("Events").Find(&events)
+	err := conn(ctx, c.db).Model(&challenge).Where("user_id = ?", user.ID).Association("Events").Find(&events)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get events: %v", err)
 	}
@@ -111,8 +111,46 @@ func (c *Challenge) GetAllEventsByUser(ctx context.Context, challenge *entity.Ch
 	return events, nil
 }
 
+func (c *Challenge) GetAllEvents(ctx context.Context, challenge *entity.Challenge) ([]*entity.ChallengeEvent, error) {
+	var events []*entity.ChallengeEvent
+	err := conn(ctx, c.db).Model(&challenge).Order("date ASC").Association("Events").Find(&events)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get events: %v", err)
+	}
+
+	return events, nil
+}
+
+// GetEventsByActivity also matches events recorded before they referenced
+// their activity.
+func (c *Challenge) GetEventsByActivity(ctx context.Context, activity *entity.Activity) ([]*entity.ChallengeEvent, error) {
+	var events []*entity.ChallengeEvent
+	result := conn(ctx, c.db).
+		Where("activity_id = ?", activity.ID).
+		Or("activity_id IS NULL AND user_id = ? AND date = ? AND distance = ?", activity.UserID, activity.Date, activity.Distance).
+		Find(&events)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get events for activity %s: %v", activity.ID, result.Error)
+	}
+
+	return events, nil
+}
+
+func (c *Challenge) DeleteEvents(ctx context.Context, events []*entity.ChallengeEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	result := conn(ctx, c.db).Delete(&events)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete events: %v", result.Error)
+	}
+
+	return nil
+}
+
 func (c *Challenge) AddUser(ctx context.Context, challenge *entity.Challenge, user *entity.User) error {
-	err := c.db.WithContext(ctx).Model(&challenge).Association("Users").Append(user)
+	err := conn(ctx, c.db).Model(&challenge).Association("Users").Append(user)
 	if err != nil {
 		return fmt.Errorf("failed to add user to challenge: %v", err)
 	}
@@ -122,8 +160,7 @@ func (c *Challenge) AddUser(ctx context.Context, challenge *entity.Challenge, us
 
 func (c *Challenge) GetRanking(ctx context.Context, challenge *entity.Challenge) ([]*entity.ChallengeRankingResult, error) {
 	var results []*entity.ChallengeRankingResult
-	err := c.db.
-		WithContext(ctx).
+	err := conn(ctx, c.db).
 		Table("challenge_events").
 		Select("user_id, SUM(distance) AS distance").
 		Where("challenge_id = ?", challenge.ID).
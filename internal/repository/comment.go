@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"runmate_api/internal/entity"
+)
+
+type Comment struct {
+	db *gorm.DB
+}
+
+func NewComment(db *gorm.DB) *Comment {
+	return &Comment{db: db}
+}
+
+func (c *Comment) Create(ctx context.Context, comment *entity.Comment) error {
+	result := conn(ctx, c.db).Create(comment)
+	if result.Error != nil {
+		return fmt.Errorf("failed to create comment: %v", result.Error)
+	}
+
+	return nil
+}
+
+func (c *Comment) GetByID(ctx context.Context, id string) (*entity.Comment, error) {
+	var comment entity.Comment
+	result := conn(ctx, c.db).Preload("User").Where("id = ?", id).First(&comment)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get comment %s: %v", id, result.Error)
+	}
+
+	return &comment, nil
+}
+
+func (c *Comment) GetByActivityID(ctx context.Context, activityID string) ([]*entity.Comment, error) {
+	var comments []*entity.Comment
+	result := conn(ctx, c.db).Preload("User").Where("activity_id = ?", activityID).Order("created_at ASC").Find(&comments)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get comments for activity %s: %v", activityID, result.Error)
+	}
+
+	return comments, nil
+}
+
+func (c *Comment) Delete(ctx context.Context, id string) error {
+	result := conn(ctx, c.db).Where("id = ?", id).Delete(&entity.Comment{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete comment %s: %v", id, result.Error)
+	}
+
+	return nil
+}
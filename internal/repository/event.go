@@ -18,7 +18,7 @@ func NewEvent(db *gorm.DB) *Event {
 }
 
 func (e *Event) Create(ctx context.Context, event *entity.Event) error {
-	result := e.db.WithContext(ctx).Create(event)
+	result := conn(ctx, e.db).Create(event)
 	if result.Error != nil {
 		return fmt.Errorf("failed to create event: %v", result.Error)
 	}
@@ -28,7 +28,7 @@ func (e *Event) Create(ctx context.Context, event *entity.Event) error {
 
 func (e *Event) GetByID(ctx context.Context, id string) (*entity.Event, error) {
 	var event entity.Event
-	result := e.db.WithContext(ctx).Preload("Users").Where("id = ?", id).First(&event)
+	result := conn(ctx, e.db).Preload("Users").Where("id = ?", id).First(&event)
 	if result.Error != nil {
 		return nil, fmt.Errorf("failed to get event %s: %v", id, result.Error)
 	}
@@ -38,7 +38,7 @@ func (e *Event) GetByID(ctx context.Context, id string) (*entity.Event, error) {
 
 func (e *Event) GetAllActive(ctx context.Context) ([]*entity.Event, error) {
 	var events []*entity.Event
-	result := e.db.WithContext(ctx).Preload("Users").Where("date >= NOW()").Find(&events)
+	result := conn(ctx, e.db).Preload("Users").Where("date >= NOW()").Find(&events)
 	if result.Error != nil {
 		return nil, fmt.Errorf("failed to get active events: %v", result.Error)
 	}
@@ -47,7 +47,7 @@ func (e *Event) GetAllActive(ctx context.Context) ([]*entity.Event, error) {
 }
 
 func (e *Event) Update(ctx context.Context, event *entity.Event) error {
-	result := e.db.WithContext(ctx).Save(event)
+	result := conn(ctx, e.db).Save(event)
 	if result.Error != nil {
 		return fmt.Errorf("failed to update event: %v", result.Error)
 	}
@@ -57,7 +57,7 @@ func (e *Event) Update(ctx context.Context, event *entity.Event) error {
 
 func (e *Event) GetAllActiveWithoutUser(ctx context.Context, user *entity.User) ([]*entity.Event, error) {
 	var events []*entity.Event
-	err := e.db.WithContext(ctx).
+	err := conn(ctx, e.db).
 		Preload("Users").
 		Table("events").
 		Select("events.*").
@@ -74,7 +74,7 @@ func (e *Event) GetAllActiveWithoutUser(ctx context.Context, user *entity.User)
 
 func (e *Event) GetAllActiveByUser(ctx context.Context, user *entity.User) ([]*entity.Event, error) {
 	var events []*entity.Event
-	err := e.db.WithContext(ctx).Model(&user).Where("date >= NOW()").Preload("Users").Association("Events").Find(&events)
+	err := conn(ctx, e.db).Model(&user).Where("date >= NOW()").Preload("Users").Association("Events").Find(&events)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user %s active events: %v", user.ID.String(), err)
 	}
@@ -84,7 +84,7 @@ func (e *Event) GetAllActiveByUser(ctx context.Context, user *entity.User) ([]*e
 
 func (e *Event) GetAllByUser(ctx context.Context, user *entity.User) ([]*entity.Event, error) {
 	var events []*entity.Event
-	err := e.db.WithContext(ctx).Model(&user).Preload("Users").Association("Events").Find(&events)
+	err := conn(ctx, e.db).Model(&user).Preload("Users").Association("Events").Find(&events)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user %s events: %v", user.ID.String(), err)
 	}
@@ -93,7 +93,7 @@ func (e *Event) GetAllByUser(ctx context.Context, user *entity.User) ([]*entity.
 }
 
 func (e *Event) AddUser(ctx context.Context, event *entity.Event, user *entity.User) error {
-	err := e.db.WithContext(ctx).Model(&event).Association("Users").Append(user)
+	err := conn(ctx, e.db).Model(&event).Association("Users").Append(user)
 	if err != nil {
 		return fmt.Errorf("failed to add user to event: %v", err)
 	}
@@ -102,7 +102,7 @@ func (e *Event) AddUser(ctx context.Context, event *entity.Event, user *entity.U
 }
 
 func (e *Event) RemoveUser(ctx context.Context, event *entity.Event, user *entity.User) error {
-	err := e.db.WithContext(ctx).Model(&event).Association("Users").Delete(user)
+	err := conn(ctx, e.db).Model(&event).Association("Users").Delete(user)
 	if err != nil {
 		return fmt.Errorf("failed to remove user from event: %v", err)
 	}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"runmate_api/internal/entity"
+)
+
+type Goal struct {
+	db *gorm.DB
+}
+
+func NewGoal(db *gorm.DB) *Goal {
+	return &Goal{db: db}
+}
+
+// MigrateLegacy moves the weekly goals once kept in the goal_days and
+// goal_daily_distance columns of users into goals, then drops the columns.
+func (g *Goal) MigrateLegacy(ctx context.Context) error {
+	db := conn(ctx, g.db)
+	if !db.Migrator().HasColumn(&entity.User{}, "goal_days") {
+		return nil
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`INSERT INTO goals (user_id, period, metric, target, daily_distance, created_at)
+			SELECT id, ?, ?, goal_days, NULLIF(goal_daily_distance, 0), created_at
+			FROM users WHERE goal_days > 0`, entity.GoalPeriodWeek, entity.GoalMetricActiveDays).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Migrator().DropColumn(&entity.User{}, "goal_days")
+		if err != nil {
+			return err
+		}
+
+		return tx.Migrator().DropColumn(&entity.User{}, "goal_daily_distance")
+	})
+	if err != nil {
+		return fmt.Errorf("failed to migrate legacy goals: %v", err)
+	}
+
+	return nil
+}
+
+func (g *Goal) Create(ctx context.Context, goal *entity.Goal) error {
+	result := conn(ctx, g.db).Create(goal)
+	if result.Error != nil {
+		return fmt.Errorf("failed to create goal: %v", result.Error)
+	}
+
+	return nil
+}
+
+// GetByUserID lists the active goals of the user or, with ended, all of them,
+// the latest first.
+func (g *Goal) GetByUserID(ctx context.Context, userID string, ended bool) ([]*entity.Goal, error) {
+	db := conn(ctx, g.db).Where("user_id = ?", userID)
+	if !ended {
+		db = db.Where("ended_at IS NULL")
+	}
+
+	var goals []*entity.Goal
+	result := db.Order("created_at DESC").Find(&goals)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get goals for user %s: %v", userID, result.Error)
+	}
+
+	return goals, nil
+}
+
+func (g *Goal) CountActiveByUserID(ctx context.Context, userID string) (int64, error) {
+	var count int64
+	result := conn(ctx, g.db).Model(&entity.Goal{}).Where("user_id = ? AND ended_at IS NULL", userID).Count(&count)
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to count goals for user %s: %v", userID, result.Error)
+	}
+
+	return count, nil
+}
+
+// End reports whether the goal was active and is now ended.
+func (g *Goal) End(ctx context.Context, userID, id string, endedAt time.Time) (bool, error) {
+	result := conn(ctx, g.db).
+		Model(&entity.Goal{}).
+		Where("id = ? AND user_id = ? AND ended_at IS NULL", id, userID).
+		Update("ended_at", endedAt)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to end goal %s: %v", id, result.Error)
+	}
+
+	return result.RowsAffected > 0, nil
+}
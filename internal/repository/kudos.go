@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"runmate_api/internal/entity"
+)
+
+type Kudos struct {
+	db *gorm.DB
+}
+
+func NewKudos(db *gorm.DB) *Kudos {
+	return &Kudos{db: db}
+}
+
+// Create reports whether the kudos is new, giving kudos twice being a no-op.
+func (k *Kudos) Create(ctx context.Context, kudos *entity.Kudos) (bool, error) {
+	result := conn(ctx, k.db).Clauses(clause.OnConflict{DoNothing: true}).Create(kudos)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to create kudos: %v", result.Error)
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+func (k *Kudos) GetByActivityID(ctx context.Context, activityID string) ([]*entity.Kudos, error) {
+	var kudos []*entity.Kudos
+	result := conn(ctx, k.db).Preload("User").Where("activity_id = ?", activityID).Order("created_at DESC").Find(&kudos)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get kudos for activity %s: %v", activityID, result.Error)
+	}
+
+	return kudos, nil
+}
+
+func (k *Kudos) Delete(ctx context.Context, activityID, userID string) error {
+	result := conn(ctx, k.db).Where("activity_id = ? AND user_id = ?", activityID, userID).Delete(&entity.Kudos{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete kudos: %v", result.Error)
+	}
+
+	return nil
+}
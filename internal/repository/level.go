@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"runmate_api/internal/entity"
+)
+
+type LevelUp struct {
+	db *gorm.DB
+}
+
+func NewLevelUp(db *gorm.DB) *LevelUp {
+	return &LevelUp{db: db}
+}
+
+// MigrateDates dates the level ups reached before they had a date by when they
+// were reached.
+func (l *LevelUp) MigrateDates(ctx context.Context) error {
+	result := conn(ctx, l.db).Exec("UPDATE level_ups SET date = created_at WHERE date IS NULL")
+	if result.Error != nil {
+		return fmt.Errorf("failed to migrate level up dates: %v", result.Error)
+	}
+
+	return nil
+}
+
+func (l *LevelUp) Create(ctx context.Context, levelUp *entity.LevelUp) error {
+	result := conn(ctx, l.db).Create(levelUp)
+	if result.Error != nil {
+		return fmt.Errorf("failed to create level up: %v", result.Error)
+	}
+
+	return nil
+}
+
+// GetFeed lists the level ups of the user's friends, and optionally their own,
+// dated from from until to, the latest first. A nil to is open.
+func (l *LevelUp) GetFeed(ctx context.Context, userID string, includeOwn bool, from time.Time, to *time.Time) ([]*entity.LevelUp, error) {
+	db := conn(ctx, l.db).
+		Joins("LEFT JOIN user_friends AS feed_friends ON feed_friends.friend_id = level_ups.user_id AND feed_friends.user_id = ?", userID)
+	if includeOwn {
+		db = db.Where("feed_friends.user_id IS NOT NULL OR level_ups.user_id = ?", userID)
+	} else {
+		db = db.Where("feed_friends.user_id IS NOT NULL")
+	}
+
+	db = db.Where("level_ups.date >= ?", from)
+	if to != nil {
+		db = db.Where("level_ups.date < ?", *to)
+	}
+
+	var levelUps []*entity.LevelUp
+	result := db.
+		Preload("User").
+		Order("level_ups.date DESC, level_ups.id DESC").
+		Find(&levelUps)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get level ups feed for user %s: %v", userID, result.Error)
+	}
+
+	return levelUps, nil
+}
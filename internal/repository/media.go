@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"runmate_api/internal/entity"
+)
+
+type Media struct {
+	db *gorm.DB
+}
+
+func NewMedia(db *gorm.DB) *Media {
+	return &Media{db: db}
+}
+
+func (m *Media) Create(ctx context.Context, media *entity.ActivityMedia) error {
+	result := conn(ctx, m.db).Create(media)
+	if result.Error != nil {
+		return fmt.Errorf("failed to create media: %v", result.Error)
+	}
+
+	return nil
+}
+
+func (m *Media) GetByID(ctx context.Context, id string) (*entity.ActivityMedia, error) {
+	var media entity.ActivityMedia
+	result := conn(ctx, m.db).Where("id = ?", id).First(&media)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get media %s: %v", id, result.Error)
+	}
+
+	return &media, nil
+}
+
+// GetByKey returns the media stored under key, as the photo or its thumbnail.
+func (m *Media) GetByKey(ctx context.Context, key string) (*entity.ActivityMedia, error) {
+	var media entity.ActivityMedia
+	result := conn(ctx, m.db).Where("key = ? OR thumbnail_key = ?", key, key).First(&media)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get media %s: %v", key, result.Error)
+	}
+
+	return &media, nil
+}
+
+func (m *Media) Delete(ctx context.Context, id string) error {
+	result := conn(ctx, m.db).Where("id = ?", id).Delete(&entity.ActivityMedia{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete media %s: %v", id, result.Error)
+	}
+
+	return nil
+}
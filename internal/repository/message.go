@@ -17,11 +17,11 @@ func NewMessage(db *gorm.DB) *Message {
 }
 
 func (r *Message) Save(ctx context.Context, message *entity.Message) error {
-	return r.db.WithContext(ctx).Create(message).Error
+	return conn(ctx, r.db).Create(message).Error
 }
 
 func (r *Message) GetAllByChallengeID(ctx context.Context, challengeID string) ([]*entity.Message, error) {
 	var messages []*entity.Message
-	err := r.db.WithContext(ctx).Where("challenge_id = ?", challengeID).Where("type = ?", entity.MessageTypeUser).Order("created_at ASC").Find(&messages).Error
+	err := conn(ctx, r.db).Where("challenge_id = ?", challengeID).Where("type = ?", entity.MessageTypeUser).Order("created_at ASC").Find(&messages).Error
 	return messages, err
 }
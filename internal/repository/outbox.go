@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"runmate_api/internal/entity"
+)
+
+type Outbox struct {
+	db *gorm.DB
+}
+
+func NewOutbox(db *gorm.DB) *Outbox {
+	return &Outbox{db: db}
+}
+
+func (o *Outbox) Create(ctx context.Context, notifications []*entity.OutboxNotification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	result := conn(ctx, o.db).Create(notifications)
+	if result.Error != nil {
+		return fmt.Errorf("failed to create outbox notifications: %v", result.Error)
+	}
+
+	return nil
+}
+
+// Claim leases the pending notifications whose next attempt is due until
+// leasedUntil, by moving their next attempt there, so other dispatchers skip
+// them while they are sent and retry them if this one stops before recording
+// the results.
+func (o *Outbox) Claim(ctx context.Context, limit int, leasedUntil time.Time) ([]*entity.OutboxNotification, error) {
+	var notifications []*entity.OutboxNotification
+	result := conn(ctx, o.db).Raw(`UPDATE outbox_notifications SET next_attempt_at = ?
+		WHERE id IN (
+			SELECT id FROM outbox_notifications
+			WHERE sent_at IS NULL AND failed_at IS NULL AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at ASC
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`, leasedUntil, limit).Scan(&notifications)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to claim due outbox notifications: %v", result.Error)
+	}
+
+	return notifications, nil
+}
+
+func (o *Outbox) Update(ctx context.Context, notification *entity.OutboxNotification) error {
+	result := conn(ctx, o.db).Save(notification)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update outbox notification %s: %v", notification.ID, result.Error)
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"runmate_api/internal/entity"
+)
+
+type PrivacyZone struct {
+	db *gorm.DB
+}
+
+func NewPrivacyZone(db *gorm.DB) *PrivacyZone {
+	return &PrivacyZone{db: db}
+}
+
+func (p *PrivacyZone) Create(ctx context.Context, zone *entity.PrivacyZone) error {
+	result := conn(ctx, p.db).Create(zone)
+	if result.Error != nil {
+		return fmt.Errorf("failed to create privacy zone: %v", result.Error)
+	}
+
+	return nil
+}
+
+func (p *PrivacyZone) GetByUserID(ctx context.Context, userID string) ([]*entity.PrivacyZone, error) {
+	var zones []*entity.PrivacyZone
+	result := conn(ctx, p.db).Where("user_id = ?", userID).Order("created_at ASC").Find(&zones)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get privacy zones for user %s: %v", userID, result.Error)
+	}
+
+	return zones, nil
+}
+
+func (p *PrivacyZone) Delete(ctx context.Context, userID, id string) error {
+	result := conn(ctx, p.db).Where("id = ? AND user_id = ?", id, userID).Delete(&entity.PrivacyZone{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete privacy zone %s: %v", id, result.Error)
+	}
+
+	return nil
+}
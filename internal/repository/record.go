@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"runmate_api/internal/entity"
+)
+
+// recordsOrder sorts each kind from its best effort: the longest distance for
+// RecordKindLongestRun, the shortest duration otherwise. Ties go to the
+// earliest effort.
+var recordsOrder = fmt.Sprintf(
+	"best_efforts.kind, CASE WHEN best_efforts.kind = %d THEN -best_efforts.distance ELSE best_efforts.duration END, best_efforts.date ASC",
+	entity.RecordKindLongestRun,
+)
+
+type Record struct {
+	db *gorm.DB
+}
+
+func NewRecord(db *gorm.DB) *Record {
+	return &Record{db: db}
+}
+
+func (r *Record) CreateEfforts(ctx context.Context, efforts []*entity.BestEffort) error {
+	if len(efforts) == 0 {
+		return nil
+	}
+
+	result := conn(ctx, r.db).Create(efforts)
+	if result.Error != nil {
+		return fmt.Errorf("failed to create best efforts: %v", result.Error)
+	}
+
+	return nil
+}
+
+func (r *Record) DeleteEffortsByActivity(ctx context.Context, activityID uuid.UUID) error {
+	result := conn(ctx, r.db).Where("activity_id = ?", activityID).Delete(&entity.BestEffort{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete best efforts of activity %s: %v", activityID, result.Error)
+	}
+
+	return nil
+}
+
+// GetByUserID returns the personal records of the user, one per kind.
+func (r *Record) GetByUserID(ctx context.Context, userID string) ([]*entity.BestEffort, error) {
+	var records []*entity.BestEffort
+	result := conn(ctx, r.db).
+		Select("DISTINCT ON (best_efforts.kind) best_efforts.*").
+		Where("best_efforts.user_id = ?", userID).
+		Order(recordsOrder).
+		Find(&records)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get records for user %s: %v", userID, result.Error)
+	}
+
+	return records, nil
+}
+
+// GetVisibleByUserID returns the personal records of the user set in
+// activities the viewer may see.
+func (r *Record) GetVisibleByUserID(ctx context.Context, userID string, viewerID uuid.UUID) ([]*entity.BestEffort, error) {
+	var records []*entity.BestEffort
+	result := conn(ctx, r.db).
+		Select("DISTINCT ON (best_efforts.kind) best_efforts.*").
+		Joins("JOIN activities ON activities.id = best_efforts.activity_id").
+		Scopes(visibleTo(viewerID)).
+		Where("best_efforts.user_id = ?", userID).
+		Order(recordsOrder).
+		Preload("Activity").
+		Find(&records)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get records for user %s: %v", userID, result.Error)
+	}
+
+	return records, nil
+}
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"runmate_api/internal/entity"
+)
+
+type Reminder struct {
+	db *gorm.DB
+}
+
+func NewReminder(db *gorm.DB) *Reminder {
+	return &Reminder{db: db}
+}
+
+// Create reports whether the reminder is new, so it was not sent yet.
+func (r *Reminder) Create(ctx context.Context, reminder *entity.Reminder) (bool, error) {
+	result := conn(ctx, r.db).Clauses(clause.OnConflict{DoNothing: true}).Create(reminder)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to create reminder: %v", result.Error)
+	}
+
+	return result.RowsAffected > 0, nil
+}
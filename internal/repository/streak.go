@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"runmate_api/internal/entity"
+)
+
+type Streak struct {
+	db *gorm.DB
+}
+
+func NewStreak(db *gorm.DB) *Streak {
+	return &Streak{db: db}
+}
+
+// CreateFreeze reports whether the freeze is new, so the period was not
+// frozen yet.
+func (s *Streak) CreateFreeze(ctx context.Context, freeze *entity.StreakFreeze) (bool, error) {
+	result := conn(ctx, s.db).Clauses(clause.OnConflict{DoNothing: true}).Create(freeze)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to create streak freeze: %v", result.Error)
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// GetFreezesByUserID lists the freezes of the user for periods starting from
+// from, a date formatted as 2006-01-02. Empty from lists all of them.
+func (s *Streak) GetFreezesByUserID(ctx context.Context, userID, from string) ([]*entity.StreakFreeze, error) {
+	db := conn(ctx, s.db).Where("user_id = ?", userID)
+	if from != "" {
+		db = db.Where("start >= ?", from)
+	}
+
+	var freezes []*entity.StreakFreeze
+	result := db.Order("start ASC").Find(&freezes)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get streak freezes for user %s: %v", userID, result.Error)
+	}
+
+	return freezes, nil
+}
+
+// DeleteFreeze reports whether the period was frozen.
+func (s *Streak) DeleteFreeze(ctx context.Context, freeze *entity.StreakFreeze) (bool, error) {
+	result := conn(ctx, s.db).
+		Where("user_id = ? AND kind = ? AND start = ?", freeze.UserID, freeze.Kind, freeze.Start).
+		Delete(&entity.StreakFreeze{})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to delete streak freeze: %v", result.Error)
+	}
+
+	return result.RowsAffected > 0, nil
+}
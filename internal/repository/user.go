@@ -2,13 +2,20 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"runmate_api/internal/entity"
 )
 
+// progressColumns hold what the user earns, written only by UpdateProgress.
+var progressColumns = []string{"xp", "streak_freezes", "freezes_earned", "level_reached"}
+
 type User struct {
 	db *gorm.DB
 }
@@ -18,7 +25,7 @@ func NewUser(db *gorm.DB) *User {
 }
 
 func (u *User) Create(ctx context.Context, user *entity.User) error {
-	result := u.db.WithContext(ctx).Create(user)
+	result := conn(ctx, u.db).Create(user)
 	if result.Error != nil {
 		return fmt.Errorf("failed to create user: %v", result.Error)
 	}
@@ -28,7 +35,7 @@ func (u *User) Create(ctx context.Context, user *entity.User) error {
 
 func (u *User) GetAll(ctx context.Context) ([]*entity.User, error) {
 	var users []*entity.User
-	result := u.db.WithContext(ctx).Find(&users)
+	result := conn(ctx, u.db).Find(&users)
 	if result.Error != nil {
 		return nil, fmt.Errorf("failed to get users: %v", result.Error)
 	}
@@ -36,9 +43,38 @@ func (u *User) GetAll(ctx context.Context) ([]*entity.User, error) {
 	return users, nil
 }
 
+// GetAllWithGoals lists the users with active goals, along with them.
+func (u *User) GetAllWithGoals(ctx context.Context) ([]*entity.User, error) {
+	var users []*entity.User
+	result := conn(ctx, u.db).
+		Preload("Goals", "ended_at IS NULL").
+		Where("EXISTS (SELECT 1 FROM goals WHERE goals.user_id = users.id AND goals.ended_at IS NULL)").
+		Find(&users)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get users with goals: %v", result.Error)
+	}
+
+	return users, nil
+}
+
+// GetAllActiveSince lists the users with approved activities of the types
+// dated from since.
+func (u *User) GetAllActiveSince(ctx context.Context, since time.Time, types []entity.ActivityType) ([]*entity.User, error) {
+	var users []*entity.User
+	result := conn(ctx, u.db).
+		Where("EXISTS (SELECT 1 FROM activities WHERE activities.user_id = users.id AND activities.review_status = ? AND activities.date >= ? AND activities.type IN ?)",
+			entity.ActivityReviewStatusApproved, since, types).
+		Find(&users)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get active users: %v", result.Error)
+	}
+
+	return users, nil
+}
+
 func (u *User) GetAllNonFriends(ctx context.Context, user *entity.User) ([]*entity.User, error) {
 	var users []*entity.User
-	result := u.db.WithContext(ctx).
+	result := conn(ctx, u.db).
 		Table("users").
 		Select("users.*").
 		Joins("LEFT JOIN user_friends ON users.id = user_friends.friend_id AND user_friends.user_id = ?", user.ID).
@@ -53,7 +89,19 @@ func (u *User) GetAllNonFriends(ctx context.Context, user *entity.User) ([]*enti
 
 func (u *User) GetByID(ctx context.Context, id string) (*entity.User, error) {
 	var user entity.User
-	result := u.db.WithContext(ctx).Where("id = ?", id).First(&user)
+	result := conn(ctx, u.db).Where("id = ?", id).First(&user)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get user %s: %v", id, result.Error)
+	}
+
+	return &user, nil
+}
+
+// GetByIDForUpdate locks the user until the end of the transaction, so that
+// changes to what they earn are made one at a time.
+func (u *User) GetByIDForUpdate(ctx context.Context, id string) (*entity.User, error) {
+	var user entity.User
+	result := conn(ctx, u.db).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&user)
 	if result.Error != nil {
 		return nil, fmt.Errorf("failed to get user %s: %v", id, result.Error)
 	}
@@ -63,7 +111,11 @@ func (u *User) GetByID(ctx context.Context, id string) (*entity.User, error) {
 
 func (u *User) GetByUsername(ctx context.Context, username string) (*entity.User, error) {
 	var user entity.User
-	result := u.db.WithContext(ctx).Where("username = ?", username).First(&user)
+	result := conn(ctx, u.db).Where("username = ?", username).First(&user)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
 	if result.Error != nil {
 		return nil, fmt.Errorf("failed to get user by username %s: %v", username, result.Error)
 	}
@@ -73,7 +125,7 @@ func (u *User) GetByUsername(ctx context.Context, username string) (*entity.User
 
 func (u *User) GetByEmail(ctx context.Context, email string) (*entity.User, error) {
 	var user entity.User
-	result := u.db.WithContext(ctx).Where("email = ?", email).First(&user)
+	result := conn(ctx, u.db).Where("email = ?", email).First(&user)
 	if result.Error != nil {
 		return nil, fmt.Errorf("failed to get user by email %s: %v", email, result.Error)
 	}
@@ -81,8 +133,10 @@ func (u *User) GetByEmail(ctx context.Context, email string) (*entity.User, erro
 	return &user, nil
 }
 
+// Update saves the user except for what they earn, which may have changed
+// since they were read.
 func (u *User) Update(ctx context.Context, user *entity.User) error {
-	result := u.db.WithContext(ctx).Save(user)
+	result := conn(ctx, u.db).Omit(progressColumns...).Save(user)
 	if result.Error != nil {
 		return fmt.Errorf("failed to update user: %v", result.Error)
 	}
@@ -90,8 +144,32 @@ func (u *User) Update(ctx context.Context, user *entity.User) error {
 	return nil
 }
 
+// UpdateProgress saves the XP, streak freezes and levels of the user, who
+// must have been read with GetByIDForUpdate.
+func (u *User) UpdateProgress(ctx context.Context, user *entity.User) error {
+	result := conn(ctx, u.db).Model(user).Select(progressColumns).Updates(user)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update progress of user %s: %v", user.ID, result.Error)
+	}
+
+	return nil
+}
+
+// SpendStreakFreeze reports whether the user had a streak freeze, now spent.
+func (u *User) SpendStreakFreeze(ctx context.Context, id uuid.UUID) (bool, error) {
+	result := conn(ctx, u.db).
+		Model(&entity.User{}).
+		Where("id = ? AND streak_freezes > 0", id).
+		Update("streak_freezes", gorm.Expr("streak_freezes - 1"))
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to spend streak freeze of user %s: %v", id, result.Error)
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
 func (u *User) Delete(ctx context.Context, id string) error {
-	result := u.db.WithContext(ctx).Where("id = ?", id).Delete(&entity.User{})
+	result := conn(ctx, u.db).Where("id = ?", id).Delete(&entity.User{})
 	if result.Error != nil {
 		return fmt.Errorf("failed to delete user %s: %v", id, result.Error)
 	}
@@ -100,7 +178,7 @@ func (u *User) Delete(ctx context.Context, id string) error {
 }
 
 func (u *User) CreateFriend(ctx context.Context, user, friend *entity.User) error {
-	err := u.db.WithContext(ctx).Model(&user).Association("Friends").Append(friend)
+	err := conn(ctx, u.db).Model(&user).Association("Friends").Append(friend)
 	if err != nil {
 		return fmt.Errorf("failed to create friend: %v", err)
 	}
@@ -110,7 +188,7 @@ func (u *User) CreateFriend(ctx context.Context, user, friend *entity.User) erro
 
 func (u *User) ListFriends(ctx context.Context, user *entity.User) ([]*entity.User, error) {
 	var friends []*entity.User
-	err := u.db.WithContext(ctx).Model(&user).Association("Friends").Find(&friends)
+	err := conn(ctx, u.db).Model(&user).Association("Friends").Find(&friends)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list friends: %v", err)
 	}
@@ -119,7 +197,7 @@ func (u *User) ListFriends(ctx context.Context, user *entity.User) ([]*entity.Us
 }
 
 func (u *User) DeleteFriend(ctx context.Context, user, friend *entity.User) error {
-	err := u.db.WithContext(ctx).Model(&user).Association("Friends").Delete(friend)
+	err := conn(ctx, u.db).Model(&user).Association("Friends").Delete(friend)
 	if err != nil {
 		return fmt.Errorf("failed to delete friend: %v", err)
 	}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"runmate_api/internal/entity"
+)
+
+// openingBalanceReason explains the entries opening the ledger of the users
+// who earned XP before it.
+const openingBalanceReason = "Saldo anterior ao histórico de XP"
+
+type XP struct {
+	db *gorm.DB
+}
+
+func NewXP(db *gorm.DB) *XP {
+	return &XP{db: db}
+}
+
+// MigrateBalances opens the ledger of the users with XP but no entries, who
+// earned it before the ledger, with an adjustment of their whole XP.
+func (x *XP) MigrateBalances(ctx context.Context) error {
+	result := conn(ctx, x.db).Exec(`INSERT INTO xp_transactions (user_id, source, amount, reason, created_at)
+		SELECT id, ?, xp, ?, NOW() FROM users
+		WHERE xp <> 0 AND NOT EXISTS (SELECT 1 FROM xp_transactions WHERE xp_transactions.user_id = users.id)`,
+		entity.XPSourceAdminAdjustment, openingBalanceReason)
+	if result.Error != nil {
+		return fmt.Errorf("failed to migrate xp balances: %v", result.Error)
+	}
+
+	return nil
+}
+
+func (x *XP) Create(ctx context.Context, transaction *entity.XPTransaction) error {
+	result := conn(ctx, x.db).Create(transaction)
+	if result.Error != nil {
+		return fmt.Errorf("failed to create xp transaction: %v", result.Error)
+	}
+
+	return nil
+}
+
+// GetByUserID lists the ledger of the user, the latest entries first.
+func (x *XP) GetByUserID(ctx context.Context, userID string) ([]*entity.XPTransaction, error) {
+	var transactions []*entity.XPTransaction
+	result := conn(ctx, x.db).Where("user_id = ?", userID).Order("created_at DESC").Find(&transactions)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get xp transactions for user %s: %v", userID, result.Error)
+	}
+
+	return transactions, nil
+}
+
+func (x *XP) GetSourceTotal(ctx context.Context, userID uuid.UUID, source entity.XPSource, sourceID uuid.UUID) (*entity.XPSourceTotal, error) {
+	var total entity.XPSourceTotal
+	result := conn(ctx, x.db).
+		Model(&entity.XPTransaction{}).
+		Select("COUNT(*) AS count, COALESCE(SUM(amount), 0) AS amount").
+		Where("user_id = ? AND source = ? AND source_id = ?", userID, source, sourceID).
+		Scan(&total)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get xp total of source %s: %v", sourceID, result.Error)
+	}
+
+	return &total, nil
+}
@@ -2,64 +2,297 @@ package service
 
 import (
 	"context"
+	"errors"
 	"maps"
+	"math"
 	"slices"
-	"sort"
+	"time"
 
+	"github.com/google/uuid"
+
+	"runmate_api/internal/anticheat"
 	"runmate_api/internal/entity"
-	"runmate_api/internal/firebase"
+	"runmate_api/internal/geo"
 	"runmate_api/internal/repository"
+	"runmate_api/internal/storage"
+)
+
+const (
+	// Client distances may differ from the track distance by this ratio or by
+	// trackDistanceMinTolerance meters, whichever is larger.
+	trackDistanceTolerance    = 0.1
+	trackDistanceMinTolerance = 100
+	// Client durations may differ from the moving or the elapsed time of the
+	// track by this ratio or by trackDurationMinTolerance seconds, whichever is
+	// larger.
+	trackDurationTolerance    = 0.1
+	trackDurationMinTolerance = 60
+
+	// Activities of the same user overlapping in time are duplicates when their
+	// distances differ by up to this ratio or duplicateDistanceMinTolerance
+	// meters, whichever is larger.
+	duplicateDistanceTolerance    = 0.05
+	duplicateDistanceMinTolerance = 50
+)
+
+// activityXPMultipliers weights the XP earned per meter by how demanding each
+// activity type is.
+var activityXPMultipliers = map[entity.ActivityType]float64{
+	entity.ActivityTypeRun:       1,
+	entity.ActivityTypeTreadmill: 1,
+	entity.ActivityTypeHike:      0.75,
+	entity.ActivityTypeWalk:      0.5,
+	entity.ActivityTypeRide:      0.25,
+}
+
+func activityXP(activity *entity.Activity) int {
+	multiplier, ok := activityXPMultipliers[activity.Type]
+	if !ok {
+		multiplier = 1
+	}
+
+	return int(math.Round(float64(activity.Distance) * multiplier))
+}
+
+var (
+	ErrActivityNotFound         = errors.New("activity not found")
+	ErrActivityDistanceMismatch = errors.New("activity distance does not match its track")
+	ErrActivityDurationMismatch = errors.New("activity duration does not match its track")
+	ErrActivityNotPendingReview = errors.New("activity is not pending review")
 )
 
+// applyTrackSummary makes the distance and duration computed from the track
+// authoritative over the values sent by the client.
+func applyTrackSummary(activity *entity.Activity) error {
+	if len(activity.Coordinates) < 2 {
+		return nil
+	}
+
+	summary := geo.Summarize(activity.Coordinates)
+	distance := int(math.Round(summary.Distance))
+	if activity.Distance > 0 {
+		tolerance := math.Max(float64(distance)*trackDistanceTolerance, trackDistanceMinTolerance)
+		if math.Abs(float64(activity.Distance-distance)) > tolerance {
+			return ErrActivityDistanceMismatch
+		}
+	}
+
+	if summary.HasTime && activity.Duration > 0 {
+		if !durationMatches(activity.Duration, summary.MovingTime) && !durationMatches(activity.Duration, summary.ElapsedTime) {
+			return ErrActivityDurationMismatch
+		}
+	}
+
+	activity.Distance = distance
+	activity.ElevationGain = int(math.Round(summary.ElevationGain))
+	if summary.HasTime {
+		activity.Duration = summary.MovingTime
+	}
+
+	return nil
+}
+
+func durationMatches(duration, trackDuration int) bool {
+	tolerance := math.Max(float64(trackDuration)*trackDurationTolerance, trackDurationMinTolerance)
+	return math.Abs(float64(duration-trackDuration)) <= tolerance
+}
+
 type Activity struct {
 	activityRepo  *repository.Activity
 	challengeRepo *repository.Challenge
+	goalRepo      *repository.Goal
+	levelUpRepo   *repository.LevelUp
+	recordRepo    *repository.Record
+	streakRepo    *repository.Streak
 	userRepo      *repository.User
+	xpRepo        *repository.XP
+	outboxRepo    *repository.Outbox
+	transactor    *repository.Transactor
+	storage       storage.Storage
 
-	firebaseClient *firebase.Client
+	levelRewards entity.LevelRewards
 }
 
-func NewActivity(activityRepo *repository.Activity, challengeRepo *repository.Challenge, userRepo *repository.User, firebaseClient *firebase.Client) *Activity {
+func NewActivity(activityRepo *repository.Activity, challengeRepo *repository.Challenge, goalRepo *repository.Goal, levelUpRepo *repository.LevelUp, recordRepo *repository.Record, streakRepo *repository.Streak, userRepo *repository.User, xpRepo *repository.XP, outboxRepo *repository.Outbox, transactor *repository.Transactor, blobStorage storage.Storage, levelRewards entity.LevelRewards) *Activity {
 	return &Activity{
 		activityRepo:  activityRepo,
 		challengeRepo: challengeRepo,
+		goalRepo:      goalRepo,
+		levelUpRepo:   levelUpRepo,
+		recordRepo:    recordRepo,
+		streakRepo:    streakRepo,
 		userRepo:      userRepo,
+		xpRepo:        xpRepo,
+		outboxRepo:    outboxRepo,
+		transactor:    transactor,
+		storage:       blobStorage,
 
-		firebaseClient: firebaseClient,
+		levelRewards: levelRewards,
 	}
 }
 
-func (a *Activity) Create(ctx context.Context, activity *entity.Activity) error {
-	owner, err := a.userRepo.GetByID(ctx, activity.UserID.String())
+// Create stores the activity and grants its rewards. When the activity was
+// already stored by a retried request or a re-upload, activity is replaced by
+// the original one and created is false.
+func (a *Activity) Create(ctx context.Context, activity *entity.Activity) (bool, error) {
+	err := authorizeOwner(ctx, activity.UserID)
 	if err != nil {
-		return err
+		return false, err
+	}
+
+	err = activity.Validate()
+	if err != nil {
+		return false, err
+	}
+
+	err = applyTrackSummary(activity)
+	if err != nil {
+		return false, err
+	}
+
+	original, err := a.findDuplicate(ctx, activity)
+	if err != nil {
+		return false, err
 	}
 
-	if owner == nil {
-		return ErrUserNotFound
+	if original != nil {
+		*activity = *original
+		return false, nil
 	}
 
 	for i, coordinate := range activity.Coordinates {
 		coordinate.Order = int(i)
 	}
 
-	err = a.activityRepo.Create(ctx, activity)
+	err = a.review(ctx, activity)
 	if err != nil {
-		return err
+		return false, err
+	}
+
+	err = a.transactor.Do(ctx, func(ctx context.Context) error {
+		owner, err := a.userRepo.GetByIDForUpdate(ctx, activity.UserID.String())
+		if err != nil {
+			return err
+		}
+
+		if owner == nil {
+			return ErrUserNotFound
+		}
+
+		err = a.activityRepo.Create(ctx, activity)
+		if err != nil {
+			return err
+		}
+
+		if activity.ReviewStatus != entity.ActivityReviewStatusApproved {
+			return nil
+		}
+
+		notifications, err := a.reward(ctx, owner, activity)
+		if err != nil {
+			return err
+		}
+
+		return a.notify(ctx, notifications)
+	})
+	if err != nil {
+		// A concurrent retry with the same idempotency key may have won the race.
+		if activity.IdempotencyKey != nil {
+			original, findErr := a.activityRepo.GetByIdempotencyKey(ctx, activity.UserID.String(), *activity.IdempotencyKey)
+			if findErr == nil && original != nil {
+				*activity = *original
+				return false, nil
+			}
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (a *Activity) findDuplicate(ctx context.Context, activity *entity.Activity) (*entity.Activity, error) {
+	if activity.IdempotencyKey != nil {
+		original, err := a.activityRepo.GetByIdempotencyKey(ctx, activity.UserID.String(), *activity.IdempotencyKey)
+		if err != nil || original != nil {
+			return original, err
+		}
 	}
 
-	owner.XP += activity.Distance
-	err = a.userRepo.Update(ctx, owner)
+	candidates, err := a.activityRepo.GetByUserIDOverlapping(ctx, activity.UserID.String(), activity.Date, activity.EndDate())
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	tolerance := math.Max(float64(activity.Distance)*duplicateDistanceTolerance, duplicateDistanceMinTolerance)
+	for _, candidate := range candidates {
+		if math.Abs(float64(candidate.Distance-activity.Distance)) <= tolerance {
+			return candidate, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// review holds back implausible activities for an administrator to approve
+// before they grant XP or challenge progress.
+func (a *Activity) review(ctx context.Context, activity *entity.Activity) error {
+	reasons := anticheat.Analyze(activity)
+
+	activity.TrackHash = anticheat.Fingerprint(activity.Coordinates)
+	if activity.TrackHash != "" {
+		duplicate, err := a.activityRepo.GetByTrackHash(ctx, activity.TrackHash, activity.ID)
+		if err != nil {
+			return err
+		}
+
+		if duplicate != nil {
+			reasons = append(reasons, anticheat.ReasonDuplicateTrack)
+		}
+	}
+
+	if len(reasons) > 0 && activity.ReviewStatus == entity.ActivityReviewStatusApproved {
+		activity.ReviewStatus = entity.ActivityReviewStatusPending
+		activity.ReviewReasons = reasons
+	}
+
+	return nil
+}
+
+// reward grants the XP, levels and challenge progress of an approved activity.
+// The returned notifications are left for the caller to queue.
+func (a *Activity) reward(ctx context.Context, owner *entity.User, activity *entity.Activity) ([]*pendingNotification, error) {
+	notifications, err := addXP(ctx, a.levelUpRepo, a.xpRepo, a.levelRewards, owner, &entity.XPTransaction{
+		Source:   entity.XPSourceActivity,
+		SourceID: &activity.ID,
+		Amount:   activityXP(activity),
+	}, activity.Date)
+	if err != nil {
+		return nil, err
+	}
+
+	if activity.Type.CountsForStreaks() {
+		err = refundStreakFreezes(ctx, a.streakRepo, owner, activity)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = a.userRepo.UpdateProgress(ctx, owner)
+	if err != nil {
+		return nil, err
 	}
 
 	ownerChallenges, err := a.challengeRepo.GetAllActiveByUser(ctx, owner)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	for _, ownerChallenge := range ownerChallenges {
+		if !ownerChallenge.Accepts(activity.Type) {
+			continue
+		}
+
 		if activity.Date.Before(ownerChallenge.StartDate) || (ownerChallenge.EndDate != nil && activity.Date.After(*ownerChallenge.EndDate)) {
 			continue
 		}
@@ -67,11 +300,12 @@ func (a *Activity) Create(ctx context.Context, activity *entity.Activity) error
 		err = a.challengeRepo.AddEvent(ctx, ownerChallenge, &entity.ChallengeEvent{
 			ChallengeID: ownerChallenge.ID,
 			UserID:      owner.ID,
+			ActivityID:  &activity.ID,
 			Distance:    activity.Distance,
 			Date:        activity.Date,
 		})
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		tokens := make(map[string]any, len(ownerChallenge.Users)-1)
@@ -85,29 +319,120 @@ func (a *Activity) Create(ctx context.Context, activity *entity.Activity) error
 
 		notificationFunc := newChallengeActivityNotification
 		if ownerChallenge.Type == entity.ChallengeTypeDistance {
-			userChallengeEvents, err := a.challengeRepo.GetAllEventsByUser(ctx, ownerChallenge, owner)
+			winner, err := a.refreshChallengeCompletion(ctx, ownerChallenge)
 			if err != nil {
-				return err
+				return nil, err
 			}
 
-			var total int
-			for _, userChallengeEvent := range userChallengeEvents {
-				total += userChallengeEvent.Distance
+			if winner == owner.ID {
+				notificationFunc = endChallengeNotification
 			}
+		}
 
-			if total >= *ownerChallenge.TotalDistance {
-				ownerChallenge.EndDate = &activity.Date
-				err = a.challengeRepo.Update(ctx, ownerChallenge)
-				if err != nil {
-					return err
-				}
+		notifications = append(notifications, &pendingNotification{
+			notification: notificationFunc(owner.Name, ownerChallenge.Title),
+			tokens:       slices.Collect(maps.Keys(tokens)),
+		})
+	}
 
-				notificationFunc = endChallengeNotification
-			}
+	goalNotifications, err := goalsReached(ctx, a.activityRepo, a.goalRepo, owner, activity)
+	if err != nil {
+		return nil, err
+	}
+
+	notifications = append(notifications, goalNotifications...)
+
+	if activity.Type.CountsForRecords() {
+		notification, err := a.recordBestEfforts(ctx, owner, activity)
+		if err != nil {
+			return nil, err
+		}
+
+		if notification != nil {
+			notifications = append(notifications, notification)
+		}
+	}
+
+	return notifications, nil
+}
+
+// recordBestEfforts stores the best efforts of the activity, marking the
+// ones that beat the personal records of the owner, who gets notified.
+func (a *Activity) recordBestEfforts(ctx context.Context, owner *entity.User, activity *entity.Activity) (*pendingNotification, error) {
+	records, err := a.recordRepo.GetByUserID(ctx, owner.ID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	recordsByKind := make(map[entity.RecordKind]*entity.BestEffort, len(records))
+	for _, record := range records {
+		recordsByKind[record.Kind] = record
+	}
+
+	efforts := bestEfforts(activity)
+	var newRecords []*entity.BestEffort
+	for _, effort := range efforts {
+		if effort.Beats(recordsByKind[effort.Kind]) {
+			effort.PersonalRecord = true
+			newRecords = append(newRecords, effort)
+		}
+	}
+
+	err = a.recordRepo.CreateEfforts(ctx, efforts)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(newRecords) == 0 || owner.FCMToken == "" {
+		return nil, nil
+	}
+
+	return &pendingNotification{
+		notification: personalRecordNotification(activity.Title, newRecords),
+		tokens:       []string{owner.FCMToken},
+	}, nil
+}
+
+// revoke undoes the XP and challenge progress granted by reward.
+func (a *Activity) revoke(ctx context.Context, owner *entity.User, activity *entity.Activity) error {
+	err := a.revokeXP(ctx, owner, activity)
+	if err != nil {
+		return err
+	}
+
+	err = a.userRepo.UpdateProgress(ctx, owner)
+	if err != nil {
+		return err
+	}
+
+	err = a.recordRepo.DeleteEffortsByActivity(ctx, activity.ID)
+	if err != nil {
+		return err
+	}
+
+	events, err := a.challengeRepo.GetEventsByActivity(ctx, activity)
+	if err != nil {
+		return err
+	}
+
+	err = a.challengeRepo.DeleteEvents(ctx, events)
+	if err != nil {
+		return err
+	}
+
+	refreshed := make(map[uuid.UUID]bool, len(events))
+	for _, event := range events {
+		if refreshed[event.ChallengeID] {
+			continue
 		}
 
-		notification := notificationFunc(owner.Name, ownerChallenge.Title)
-		err = a.firebaseClient.SendNotification(ctx, notification, slices.Collect(maps.Keys(tokens)))
+		refreshed[event.ChallengeID] = true
+		challenge, err := a.challengeRepo.GetByID(ctx, event.ChallengeID.String())
+		if err != nil {
+			return err
+		}
+
+		_, err = a.refreshChallengeCompletion(ctx, challenge)
 		if err != nil {
 			return err
 		}
@@ -116,15 +441,108 @@ func (a *Activity) Create(ctx context.Context, activity *entity.Activity) error
 	return nil
 }
 
-func (a *Activity) ListAll(ctx context.Context) ([]*entity.Activity, error) {
-	return a.activityRepo.GetAll(ctx)
+// revokeXP takes back the XP the ledger holds for the activity. Activities
+// rewarded before the ledger have no entries, so their XP is computed again,
+// without going below zero.
+func (a *Activity) revokeXP(ctx context.Context, owner *entity.User, activity *entity.Activity) error {
+	total, err := a.xpRepo.GetSourceTotal(ctx, owner.ID, entity.XPSourceActivity, activity.ID)
+	if err != nil {
+		return err
+	}
+
+	amount := total.Amount
+	if total.Count == 0 {
+		amount = min(activityXP(activity), owner.XP)
+	}
+
+	// Taking XP back reaches no level, so there is nothing to notify.
+	_, err = addXP(ctx, a.levelUpRepo, a.xpRepo, a.levelRewards, owner, &entity.XPTransaction{
+		Source:   entity.XPSourceActivity,
+		SourceID: &activity.ID,
+		Amount:   -amount,
+	}, activity.Date)
+	return err
+}
+
+// refreshChallengeCompletion ends a distance challenge on the date its first
+// participant reached the total distance, or reopens it when nobody has. It
+// returns the winner, or uuid.Nil.
+func (a *Activity) refreshChallengeCompletion(ctx context.Context, challenge *entity.Challenge) (uuid.UUID, error) {
+	if challenge.Type != entity.ChallengeTypeDistance || challenge.TotalDistance == nil {
+		return uuid.Nil, nil
+	}
+
+	events, err := a.challengeRepo.GetAllEvents(ctx, challenge)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	var (
+		winner  uuid.UUID
+		endDate *time.Time
+	)
+	totals := make(map[uuid.UUID]int)
+	for _, event := range events {
+		totals[event.UserID] += event.Distance
+		if totals[event.UserID] >= *challenge.TotalDistance {
+			winner = event.UserID
+			endDate = &event.Date
+			break
+		}
+	}
+
+	if endDate == nil && challenge.EndDate == nil {
+		return winner, nil
+	}
+
+	if endDate != nil && challenge.EndDate != nil && endDate.Equal(*challenge.EndDate) {
+		return winner, nil
+	}
+
+	challenge.EndDate = endDate
+	return winner, a.challengeRepo.Update(ctx, challenge)
 }
 
-func (a *Activity) ListByUser(ctx context.Context, userID string) ([]*entity.Activity, error) {
-	return a.activityRepo.GetByUserID(ctx, userID)
+func (a *Activity) notify(ctx context.Context, notifications []*pendingNotification) error {
+	return enqueueNotifications(ctx, a.outboxRepo, notifications)
 }
 
-func (a *Activity) ListAllFromUserFriends(ctx context.Context, userID string) ([]*entity.Activity, error) {
+func (a *Activity) ListAll(ctx context.Context, filter *entity.ActivityFilter) (*entity.ActivityPage, error) {
+	viewer, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	activities, err := a.activityRepo.GetAll(ctx, viewer.ID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return entity.NewActivityPage(activities, filter), nil
+}
+
+func (a *Activity) ListByUser(ctx context.Context, userID string, filter *entity.ActivityFilter) (*entity.ActivityPage, error) {
+	viewer, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	activities, err := a.activityRepo.GetByUserID(ctx, userID, viewer.ID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return entity.NewActivityPage(activities, filter), nil
+}
+
+// ListFeed lists the activities of the user's friends, and optionally their
+// own, as the caller may see them.
+func (a *Activity) ListFeed(ctx context.Context, userID string, includeOwn bool, filter *entity.ActivityFilter) (*entity.ActivityPage, error) {
+	viewer, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	user, err := a.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		return nil, err
@@ -134,28 +552,296 @@ func (a *Activity) ListAllFromUserFriends(ctx context.Context, userID string) ([
 		return nil, ErrUserNotFound
 	}
 
-	friends, err := a.userRepo.ListFriends(ctx, user)
+	activities, err := a.activityRepo.GetFeed(ctx, user.ID.String(), includeOwn, viewer.ID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := entity.NewActivityPage(activities, filter)
+
+	if len(page.Activities) == 0 {
+		return page, nil
+	}
+
+	// The level ups go along the page reaching back as far as its oldest
+	// activity, from where the previous page stopped, so that pages never
+	// repeat them.
+	from := page.Activities[len(page.Activities)-1].Date
+	var to *time.Time
+	if filter != nil {
+		to = filter.To
+		if filter.After != nil {
+			to = &filter.After.Date
+		}
+	}
+
+	page.LevelUps, err = a.levelUpRepo.GetFeed(ctx, user.ID.String(), includeOwn, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// GetByID returns the activity as the caller may see it, hiding the ones they
+// may not see and the coordinates inside the owner's privacy zones.
+func (a *Activity) GetByID(ctx context.Context, id string) (*entity.Activity, error) {
+	viewer, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	activity, err := a.activityRepo.GetVisibleByID(ctx, id, viewer.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if activity == nil {
+		return nil, ErrActivityNotFound
+	}
+
+	return activity, nil
+}
+
+// get returns the whole activity, regardless of who is asking.
+func (a *Activity) get(ctx context.Context, id string) (*entity.Activity, error) {
+	activity, err := a.activityRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if activity == nil {
+		return nil, ErrActivityNotFound
+	}
+
+	return activity, nil
+}
+
+func (a *Activity) Splits(ctx context.Context, id string, unit float64) (*geo.Splits, error) {
+	activity, err := a.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return geo.ComputeSplits(activity.Coordinates, unit)
+}
+
+func (a *Activity) ListPendingReview(ctx context.Context) ([]*entity.Activity, error) {
+	err := AuthorizeAdmin(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	var activities []*entity.Activity
-	for _, friend := range friends {
-		activitiesFromFriend, err := a.activityRepo.GetByUserID(ctx, friend.ID.String())
+	return a.activityRepo.GetByReviewStatus(ctx, entity.ActivityReviewStatusPending)
+}
+
+func (a *Activity) Approve(ctx context.Context, id string) error {
+	err := AuthorizeAdmin(ctx)
+	if err != nil {
+		return err
+	}
+
+	return a.transactor.Do(ctx, func(ctx context.Context) error {
+		activity, err := a.getPendingReview(ctx, id)
 		if err != nil {
-			return nil, err
+			return err
+		}
+
+		activity.ReviewStatus = entity.ActivityReviewStatusApproved
+		err = a.activityRepo.Update(ctx, activity)
+		if err != nil {
+			return err
+		}
+
+		owner, err := a.userRepo.GetByIDForUpdate(ctx, activity.UserID.String())
+		if err != nil {
+			return err
 		}
 
-		activities = append(activities, activitiesFromFriend...)
+		notifications, err := a.reward(ctx, owner, activity)
+		if err != nil {
+			return err
+		}
+
+		return a.notify(ctx, notifications)
+	})
+}
+
+func (a *Activity) Reject(ctx context.Context, id string) error {
+	err := AuthorizeAdmin(ctx)
+	if err != nil {
+		return err
 	}
 
-	sort.Slice(activities, func(i, j int) bool {
-		return activities[i].Date.Before(activities[j].Date)
+	return a.transactor.Do(ctx, func(ctx context.Context) error {
+		activity, err := a.getPendingReview(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		activity.ReviewStatus = entity.ActivityReviewStatusRejected
+		return a.activityRepo.Update(ctx, activity)
 	})
+}
+
+// getPendingReview locks the activity until the end of the transaction, so
+// that concurrent reviews see the decision of the first one.
+func (a *Activity) getPendingReview(ctx context.Context, id string) (*entity.Activity, error) {
+	activity, err := a.activityRepo.GetByIDForUpdate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
 
-	return activities, nil
+	if activity == nil {
+		return nil, ErrActivityNotFound
+	}
+
+	if activity.ReviewStatus != entity.ActivityReviewStatusPending {
+		return nil, ErrActivityNotPendingReview
+	}
+
+	return activity, nil
+}
+
+// Update replaces the activity details and, when coordinates are given, its
+// track. When the track, date, distance, duration or type change, the rewards
+// of the previous version are revoked and granted again for the new one, which
+// is reviewed again unless an administrator approved it and the track is the
+// same.
+func (a *Activity) Update(ctx context.Context, activity *entity.Activity) error {
+	current, err := a.get(ctx, activity.ID.String())
+	if err != nil {
+		return err
+	}
+
+	err = authorizeOwner(ctx, current.UserID)
+	if err != nil {
+		return err
+	}
+
+	replaceTrack := activity.Coordinates != nil
+	updated := *current
+	updated.Title = activity.Title
+	updated.Type = activity.Type
+	updated.Date = activity.Date
+	updated.Duration = activity.Duration
+	updated.Distance = activity.Distance
+	updated.Visibility = activity.Visibility
+	if replaceTrack {
+		updated.Coordinates = activity.Coordinates
+		for i, coordinate := range updated.Coordinates {
+			coordinate.Order = int(i)
+		}
+	}
+
+	err = updated.Validate()
+	if err != nil {
+		return err
+	}
+
+	err = applyTrackSummary(&updated)
+	if err != nil {
+		return err
+	}
+
+	rewarded := replaceTrack ||
+		!updated.Date.Equal(current.Date) ||
+		updated.Distance != current.Distance ||
+		updated.Duration != current.Duration ||
+		updated.Type != current.Type
+	// Approved activities with review reasons were approved by an
+	// administrator despite them.
+	adminApproved := current.ReviewStatus == entity.ActivityReviewStatusApproved && len(current.ReviewReasons) > 0
+
+	err = a.transactor.Do(ctx, func(ctx context.Context) error {
+		if !rewarded {
+			return a.activityRepo.Update(ctx, &updated)
+		}
+
+		owner, err := a.userRepo.GetByIDForUpdate(ctx, current.UserID.String())
+		if err != nil {
+			return err
+		}
+
+		if current.ReviewStatus == entity.ActivityReviewStatusApproved {
+			err = a.revoke(ctx, owner, current)
+			if err != nil {
+				return err
+			}
+		}
+
+		if adminApproved && replaceTrack {
+			updated.ReviewReasons = nil
+		}
+
+		if replaceTrack || !adminApproved {
+			err = a.review(ctx, &updated)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = a.activityRepo.Update(ctx, &updated)
+		if err != nil {
+			return err
+		}
+
+		if replaceTrack {
+			err = a.activityRepo.ReplaceCoordinates(ctx, &updated, updated.Coordinates)
+			if err != nil {
+				return err
+			}
+		}
+
+		if updated.ReviewStatus != entity.ActivityReviewStatusApproved {
+			return nil
+		}
+
+		notifications, err := a.reward(ctx, owner, &updated)
+		if err != nil {
+			return err
+		}
+
+		return a.notify(ctx, notifications)
+	})
+	if err != nil {
+		return err
+	}
+
+	*activity = updated
+	return nil
 }
 
 func (a *Activity) Delete(ctx context.Context, id string) error {
-	return a.activityRepo.Delete(ctx, id)
+	activity, err := a.get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = authorizeOwner(ctx, activity.UserID)
+	if err != nil {
+		return err
+	}
+
+	err = a.transactor.Do(ctx, func(ctx context.Context) error {
+		if activity.ReviewStatus == entity.ActivityReviewStatusApproved {
+			owner, err := a.userRepo.GetByIDForUpdate(ctx, activity.UserID.String())
+			if err != nil {
+				return err
+			}
+
+			err = a.revoke(ctx, owner, activity)
+			if err != nil {
+				return err
+			}
+		}
+
+		return a.activityRepo.Delete(ctx, id)
+	})
+	if err != nil {
+		return err
+	}
+
+	deleteBlobs(ctx, a.storage, activity.Media)
+	return nil
 }
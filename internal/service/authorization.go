@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+
+	"runmate_api/internal/auth"
+	"runmate_api/internal/entity"
+)
+
+var (
+	ErrUnauthenticated = errors.New("unauthenticated")
+	ErrForbidden       = errors.New("forbidden")
+)
+
+func caller(ctx context.Context) (*entity.User, error) {
+	user, ok := auth.UserFromContext(ctx)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+
+	return user, nil
+}
+
+func AuthorizeAdmin(ctx context.Context) error {
+	user, err := caller(ctx)
+	if err != nil {
+		return err
+	}
+
+	if user.Role != entity.UserRoleAdmin {
+		return ErrForbidden
+	}
+
+	return nil
+}
+
+// authorizeOwner allows the resource owner and administrators.
+func authorizeOwner(ctx context.Context, ownerID uuid.UUID) error {
+	user, err := caller(ctx)
+	if err != nil {
+		return err
+	}
+
+	if user.ID != ownerID && user.Role != entity.UserRoleAdmin {
+		return ErrForbidden
+	}
+
+	return nil
+}
+
+func authorizeUser(ctx context.Context, userID string) error {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+
+	return authorizeOwner(ctx, id)
+}
@@ -43,6 +43,11 @@ func NewChallenge(challengeRepo *repository.Challenge, userRepo *repository.User
 }
 
 func (c *Challenge) Create(ctx context.Context, challenge *entity.Challenge) error {
+	err := authorizeOwner(ctx, challenge.CreatedBy)
+	if err != nil {
+		return err
+	}
+
 	user, err := c.userRepo.GetByID(ctx, challenge.CreatedBy.String())
 	if err != nil {
 		return err
@@ -60,6 +65,32 @@ func (c *Challenge) GetByID(ctx context.Context, id string) (*entity.Challenge,
 	return c.challengeRepo.GetByID(ctx, id)
 }
 
+func (c *Challenge) Update(ctx context.Context, challenge *entity.Challenge) error {
+	currentChallenge, err := c.challengeRepo.GetByID(ctx, challenge.ID.String())
+	if err != nil {
+		return err
+	}
+
+	if currentChallenge == nil {
+		return ErrChallengeNotFound
+	}
+
+	err = authorizeOwner(ctx, currentChallenge.CreatedBy)
+	if err != nil {
+		return err
+	}
+
+	currentChallenge.Title = challenge.Title
+	currentChallenge.Description = challenge.Description
+	err = c.challengeRepo.Update(ctx, currentChallenge)
+	if err != nil {
+		return err
+	}
+
+	*challenge = *currentChallenge
+	return nil
+}
+
 func (c *Challenge) ListAllActive(ctx context.Context) ([]*entity.Challenge, error) {
 	return c.challengeRepo.GetAllActive(ctx)
 }
@@ -145,6 +176,11 @@ func (c *Challenge) GetRanking(ctx context.Context, challenge *entity.Challenge)
 }
 
 func (c *Challenge) Join(ctx context.Context, challengeID, userID string) error {
+	err := authorizeUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
 	user, err := c.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		return err
@@ -43,6 +43,11 @@ func NewEvent(eventRepo *repository.Event, userRepo *repository.User, firebaseCl
 }
 
 func (c *Event) Create(ctx context.Context, event *entity.Event) error {
+	err := authorizeOwner(ctx, event.CreatedBy)
+	if err != nil {
+		return err
+	}
+
 	owner, err := c.userRepo.GetByID(ctx, event.CreatedBy.String())
 	if err != nil {
 		return err
@@ -124,6 +129,11 @@ func (c *Event) ListAllByUserID(ctx context.Context, userID string) ([]*entity.E
 }
 
 func (c *Event) Join(ctx context.Context, eventID, userID string) error {
+	err := authorizeUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
 	user, err := c.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		return err
@@ -146,6 +156,11 @@ func (c *Event) Join(ctx context.Context, eventID, userID string) error {
 }
 
 func (c *Event) Quit(ctx context.Context, eventID, userID string) error {
+	err := authorizeUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
 	user, err := c.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		return err
@@ -0,0 +1,449 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"runmate_api/internal/entity"
+	"runmate_api/internal/firebase"
+	"runmate_api/internal/repository"
+)
+
+const (
+	goalReachedNotificationTitle = "Meta batida! 🎯"
+	goalAtRiskNotificationTitle  = "Sua meta está em risco ⚠️"
+
+	// goalHistoryPeriods is how many of the latest periods the goal progress
+	// lists.
+	goalHistoryPeriods = 12
+	// goalReminderHour is the local hour from which users are warned that
+	// their goal is at risk.
+	goalReminderHour = 18
+)
+
+var (
+	ErrGoalNotFound     = errors.New("goal not found")
+	ErrGoalLimitReached = errors.New("goal limit reached")
+)
+
+var goalPeriodNames = map[entity.GoalPeriod]string{
+	entity.GoalPeriodDay:   "por dia",
+	entity.GoalPeriodWeek:  "por semana",
+	entity.GoalPeriodMonth: "por mês",
+}
+
+// formatGoalValue writes an amount of the metric, such as 2.5 km or 3h30.
+func formatGoalValue(metric entity.GoalMetric, value int) string {
+	switch metric {
+	case entity.GoalMetricDistance:
+		return strconv.FormatFloat(math.Round(float64(value)/100)/10, 'f', -1, 64) + " km"
+	case entity.GoalMetricDuration:
+		hours, minutes := value/3600, value%3600/60
+		if hours == 0 {
+			return fmt.Sprintf("%d min", minutes)
+		}
+
+		if minutes == 0 {
+			return fmt.Sprintf("%dh", hours)
+		}
+
+		return fmt.Sprintf("%dh%02d", hours, minutes)
+	case entity.GoalMetricActivities:
+		if value == 1 {
+			return "1 atividade"
+		}
+
+		return fmt.Sprintf("%d atividades", value)
+	default:
+		if value == 1 {
+			return "1 dia de atividade"
+		}
+
+		return fmt.Sprintf("%d dias de atividade", value)
+	}
+}
+
+func goalDescription(goal *entity.Goal) string {
+	return formatGoalValue(goal.Metric, goal.Target) + " " + goalPeriodNames[goal.Period]
+}
+
+func goalReachedNotification(goal *entity.Goal) *firebase.Notification {
+	return &firebase.Notification{
+		Title: goalReachedNotificationTitle,
+		Body:  fmt.Sprintf("Você completou sua meta de %s. Continue assim!", goalDescription(goal)),
+	}
+}
+
+func goalAtRiskNotification(goal *entity.Goal, missing int) *firebase.Notification {
+	return &firebase.Notification{
+		Title: goalAtRiskNotificationTitle,
+		Body: fmt.Sprintf("Você ainda precisa de %s para bater sua meta de %s. Corra hoje para não perder a meta!",
+			formatGoalValue(goal.Metric, missing), goalDescription(goal)),
+	}
+}
+
+// periodOf is the start of the goal period of t for the user.
+func periodOf(user *entity.User, period entity.GoalPeriod, t time.Time) time.Time {
+	switch period {
+	case entity.GoalPeriodDay:
+		return user.StartOfDay(t)
+	case entity.GoalPeriodWeek:
+		return user.StartOfWeek(t)
+	default:
+		return user.StartOfMonth(t)
+	}
+}
+
+func nextPeriod(period entity.GoalPeriod) func(time.Time) time.Time {
+	switch period {
+	case entity.GoalPeriodDay:
+		return nextDay
+	case entity.GoalPeriodWeek:
+		return nextWeek
+	default:
+		return nextMonth
+	}
+}
+
+// goalValue is how much the day adds toward the goal.
+func goalValue(goal *entity.Goal, day *entity.ActivityTotals) int {
+	switch goal.Metric {
+	case entity.GoalMetricDistance:
+		return day.Distance
+	case entity.GoalMetricDuration:
+		return day.Duration
+	case entity.GoalMetricActivities:
+		return day.Count
+	default:
+		if day.Count == 0 || (goal.DailyDistance != nil && day.Distance < *goal.DailyDistance) {
+			return 0
+		}
+
+		return 1
+	}
+}
+
+// goalDays returns the daily totals, from from, of the activities of the user
+// the viewer may see counting toward the goal.
+func goalDays(ctx context.Context, activityRepo *repository.Activity, user *entity.User, viewerID uuid.UUID, goal *entity.Goal, from time.Time) ([]*entity.ActivityTotals, error) {
+	return activityRepo.GetTotals(ctx, user.ID.String(), viewerID, &entity.TotalsFilter{
+		Period:   entity.TotalsPeriodDay,
+		Location: user.Location(),
+		From:     &from,
+		Types:    goal.ActivityTypes,
+	})
+}
+
+// evaluateGoal evaluates the goal from the period it was created in until the
+// current one or, once ended, the one it ended in.
+func evaluateGoal(user *entity.User, goal *entity.Goal, days []*entity.ActivityTotals, now time.Time) *entity.GoalProgress {
+	if goal.EndedAt != nil {
+		now = *goal.EndedAt
+	}
+
+	next := nextPeriod(goal.Period)
+	last := periodOf(user, goal.Period, now)
+	var periods []*entity.GoalPeriodProgress
+	periodsByStart := make(map[int64]*entity.GoalPeriodProgress)
+	for period := periodOf(user, goal.Period, goal.CreatedAt); !period.After(last); period = next(period) {
+		progress := &entity.GoalPeriodProgress{Start: period}
+		periods = append(periods, progress)
+		periodsByStart[period.Unix()] = progress
+	}
+
+	for _, day := range days {
+		if period, ok := periodsByStart[periodOf(user, goal.Period, day.Start).Unix()]; ok {
+			period.Value += goalValue(goal, day)
+		}
+	}
+
+	progress := &entity.GoalProgress{}
+	for i, period := range periods {
+		period.Met = period.Value >= goal.Target
+		if period.Met {
+			progress.CurrentStreak++
+			progress.LongestStreak = max(progress.LongestStreak, progress.CurrentStreak)
+		} else if i < len(periods)-1 {
+			progress.CurrentStreak = 0
+		}
+	}
+
+	progress.Periods = periods[max(len(periods)-goalHistoryPeriods, 0):]
+	return progress
+}
+
+// evaluateGoals sets the progress of each goal of the user, counting the
+// activities the viewer may see.
+func evaluateGoals(ctx context.Context, activityRepo *repository.Activity, user *entity.User, viewerID uuid.UUID, goals []*entity.Goal, now time.Time) error {
+	for _, goal := range goals {
+		days, err := goalDays(ctx, activityRepo, user, viewerID, goal, periodOf(user, goal.Period, goal.CreatedAt))
+		if err != nil {
+			return err
+		}
+
+		goal.Progress = evaluateGoal(user, goal, days, now)
+	}
+
+	return nil
+}
+
+// goalsReached notifies the owner of each goal the activity is the one
+// completing in the current period, that is, the period meets the goal only
+// with it.
+func goalsReached(ctx context.Context, activityRepo *repository.Activity, goalRepo *repository.Goal, owner *entity.User, activity *entity.Activity) ([]*pendingNotification, error) {
+	if owner.FCMToken == "" {
+		return nil, nil
+	}
+
+	goals, err := goalRepo.GetByUserID(ctx, owner.ID.String(), false)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	activityDay := owner.StartOfDay(activity.Date)
+	var notifications []*pendingNotification
+	for _, goal := range goals {
+		start := periodOf(owner, goal.Period, now)
+		if !goal.Accepts(activity.Type) || activity.Date.Before(start) {
+			continue
+		}
+
+		days, err := goalDays(ctx, activityRepo, owner, owner.ID, goal, start)
+		if err != nil {
+			return nil, err
+		}
+
+		var value, contribution int
+		for _, day := range days {
+			value += goalValue(goal, day)
+			if !day.Start.Equal(activityDay) {
+				continue
+			}
+
+			withoutActivity := *day
+			withoutActivity.Count--
+			withoutActivity.Distance -= activity.Distance
+			withoutActivity.Duration -= activity.Duration
+			contribution = goalValue(goal, day) - goalValue(goal, &withoutActivity)
+		}
+
+		if value < goal.Target || value-contribution >= goal.Target {
+			continue
+		}
+
+		notifications = append(notifications, &pendingNotification{
+			notification: goalReachedNotification(goal),
+			tokens:       []string{owner.FCMToken},
+		})
+	}
+
+	return notifications, nil
+}
+
+type Goal struct {
+	activityRepo *repository.Activity
+	goalRepo     *repository.Goal
+	reminderRepo *repository.Reminder
+	userRepo     *repository.User
+	outboxRepo   *repository.Outbox
+	transactor   *repository.Transactor
+}
+
+func NewGoal(activityRepo *repository.Activity, goalRepo *repository.Goal, reminderRepo *repository.Reminder, userRepo *repository.User, outboxRepo *repository.Outbox, transactor *repository.Transactor) *Goal {
+	return &Goal{
+		activityRepo: activityRepo,
+		goalRepo:     goalRepo,
+		reminderRepo: reminderRepo,
+		userRepo:     userRepo,
+		outboxRepo:   outboxRepo,
+		transactor:   transactor,
+	}
+}
+
+// ListByUser returns the active goals of the user or, with ended, all of
+// them, along with their progress.
+func (g *Goal) ListByUser(ctx context.Context, userID string, ended bool) ([]*entity.Goal, error) {
+	viewer, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := g.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	goals, err := g.goalRepo.GetByUserID(ctx, user.ID.String(), ended)
+	if err != nil {
+		return nil, err
+	}
+
+	err = evaluateGoals(ctx, g.activityRepo, user, viewer.ID, goals, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return goals, nil
+}
+
+func (g *Goal) Create(ctx context.Context, goal *entity.Goal) error {
+	err := authorizeOwner(ctx, goal.UserID)
+	if err != nil {
+		return err
+	}
+
+	err = goal.Validate()
+	if err != nil {
+		return err
+	}
+
+	user, err := g.userRepo.GetByID(ctx, goal.UserID.String())
+	if err != nil {
+		return err
+	}
+
+	count, err := g.goalRepo.CountActiveByUserID(ctx, user.ID.String())
+	if err != nil {
+		return err
+	}
+
+	if count >= entity.GoalMaxActive {
+		return ErrGoalLimitReached
+	}
+
+	err = g.goalRepo.Create(ctx, goal)
+	if err != nil {
+		return err
+	}
+
+	return evaluateGoals(ctx, g.activityRepo, user, user.ID, []*entity.Goal{goal}, time.Now())
+}
+
+// End ends the goal, which is kept in the history of the user.
+func (g *Goal) End(ctx context.Context, userID, goalID string) error {
+	err := authorizeUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	ended, err := g.goalRepo.End(ctx, userID, goalID, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if !ended {
+		return ErrGoalNotFound
+	}
+
+	return nil
+}
+
+// WarnAtRisk warns, in the evening, the users who are about to miss one of
+// their goals unless they are active today.
+func (g *Goal) WarnAtRisk(ctx context.Context, now time.Time) error {
+	users, err := g.userRepo.GetAllWithGoals(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		err = g.warnAtRisk(ctx, user, now)
+		if err != nil {
+			log.Printf("Failed to warn user %s about their goals: %v\n", user.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// warnAtRisk sends at most one warning a day, about the first goal at risk.
+func (g *Goal) warnAtRisk(ctx context.Context, user *entity.User, now time.Time) error {
+	if user.FCMToken == "" || now.In(user.Location()).Hour() < goalReminderHour {
+		return nil
+	}
+
+	for _, goal := range user.Goals {
+		missing, err := g.missingAtRisk(ctx, user, goal, now)
+		if err != nil {
+			return err
+		}
+
+		if missing == 0 {
+			continue
+		}
+
+		return g.transactor.Do(ctx, func(ctx context.Context) error {
+			created, err := g.reminderRepo.Create(ctx, &entity.Reminder{
+				UserID: user.ID,
+				Kind:   entity.ReminderKindGoalAtRisk,
+				Day:    user.StartOfDay(now).Format("2006-01-02"),
+			})
+			if err != nil || !created {
+				return err
+			}
+
+			return enqueueNotifications(ctx, g.outboxRepo, []*pendingNotification{{
+				notification: goalAtRiskNotification(goal, missing),
+				tokens:       []string{user.FCMToken},
+			}})
+		})
+	}
+
+	return nil
+}
+
+// missingAtRisk returns what is missing to meet the goal in the current
+// period when it is at risk, or 0. Distance and duration goals are at risk on
+// the last day of the period, while activities and active days goals are as
+// soon as the user must be active on every day left, today included, and was
+// not active today yet.
+func (g *Goal) missingAtRisk(ctx context.Context, user *entity.User, goal *entity.Goal, now time.Time) (int, error) {
+	start := periodOf(user, goal.Period, now)
+	days, err := goalDays(ctx, g.activityRepo, user, user.ID, goal, start)
+	if err != nil {
+		return 0, err
+	}
+
+	today := user.StartOfDay(now)
+	var value, todayValue int
+	for _, day := range days {
+		value += goalValue(goal, day)
+		if day.Start.Equal(today) {
+			todayValue = goalValue(goal, day)
+		}
+	}
+
+	var daysLeft int
+	for day, end := today, nextPeriod(goal.Period)(start); day.Before(end); day = nextDay(day) {
+		daysLeft++
+	}
+
+	missing := goal.Target - value
+	var atRisk bool
+	switch goal.Metric {
+	case entity.GoalMetricActiveDays:
+		atRisk = todayValue == 0 && missing == daysLeft
+	case entity.GoalMetricActivities:
+		atRisk = todayValue == 0 && missing >= daysLeft
+	default:
+		atRisk = daysLeft == 1
+	}
+
+	if missing <= 0 || !atRisk {
+		return 0, nil
+	}
+
+	return missing, nil
+}
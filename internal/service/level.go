@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"runmate_api/internal/entity"
+	"runmate_api/internal/firebase"
+	"runmate_api/internal/repository"
+)
+
+const levelUpNotificationTitle = "Você subiu de nível! 🎉"
+
+// levelReward is a row of the reward table as configured.
+type levelReward struct {
+	Level         int    `json:"level"`
+	ProfileFrame  string `json:"profile_frame"`
+	StreakFreezes int    `json:"streak_freezes"`
+}
+
+// NewLevelRewards parses the reward table of the levels from a JSON list such
+// as [{"level": 5, "profile_frame": "bronze"}, {"level": 10, "streak_freezes": 1}],
+// falling back to entity.DefaultLevelRewards when data is empty.
+func NewLevelRewards(data []byte) (entity.LevelRewards, error) {
+	if len(data) == 0 {
+		return entity.DefaultLevelRewards, nil
+	}
+
+	var rows []*levelReward
+	err := json.Unmarshal(data, &rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse level rewards: %v", err)
+	}
+
+	rewards := make(entity.LevelRewards, 0, len(rows))
+	for _, row := range rows {
+		rewards = append(rewards, &entity.LevelReward{
+			Level:         row.Level,
+			ProfileFrame:  row.ProfileFrame,
+			StreakFreezes: row.StreakFreezes,
+		})
+	}
+
+	err = rewards.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return rewards, nil
+}
+
+func levelUpNotification(level int, rewards []*entity.LevelReward) *firebase.Notification {
+	body := []string{fmt.Sprintf("Você alcançou o nível %d.", level)}
+	for _, reward := range rewards {
+		if reward.ProfileFrame != "" {
+			body = append(body, fmt.Sprintf("Você desbloqueou a moldura de perfil %s.", reward.ProfileFrame))
+		}
+
+		switch {
+		case reward.StreakFreezes == 1:
+			body = append(body, "Você ganhou 1 bloqueio de sequência.")
+		case reward.StreakFreezes > 1:
+			body = append(body, fmt.Sprintf("Você ganhou %d bloqueios de sequência.", reward.StreakFreezes))
+		}
+	}
+
+	return &firebase.Notification{
+		Title: levelUpNotificationTitle,
+		Body:  strings.Join(body, " "),
+	}
+}
+
+// levelUp records each level above reached that the XP of the user now
+// reaches, in the feed at date and in the ledger, and grants its rewards.
+// Callers save the user and queue the returned notifications.
+func levelUp(ctx context.Context, levelUpRepo *repository.LevelUp, xpRepo *repository.XP, rewards entity.LevelRewards, user *entity.User, reached int, date time.Time) ([]*pendingNotification, error) {
+	var notifications []*pendingNotification
+	for level := reached + 1; level <= user.CurrentLevel(); level++ {
+		event := &entity.LevelUp{
+			UserID: user.ID,
+			Level:  level,
+			Date:   date,
+		}
+
+		err := levelUpRepo.Create(ctx, event)
+		if err != nil {
+			return nil, err
+		}
+
+		err = xpRepo.Create(ctx, &entity.XPTransaction{
+			UserID:   user.ID,
+			Source:   entity.XPSourceLevelUp,
+			SourceID: &event.ID,
+			Reason:   fmt.Sprintf("Nível %d alcançado", level),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		levelRewards := rewards.At(level)
+		for _, reward := range levelRewards {
+			user.StreakFreezes = min(user.StreakFreezes+reward.StreakFreezes, entity.StreakFreezeMax)
+		}
+
+		if user.FCMToken != "" {
+			notifications = append(notifications, &pendingNotification{
+				notification: levelUpNotification(level, levelRewards),
+				tokens:       []string{user.FCMToken},
+			})
+		}
+	}
+
+	user.LevelReached = max(reached, user.CurrentLevel())
+	return notifications, nil
+}
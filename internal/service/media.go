@@ -0,0 +1,169 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+
+	"github.com/google/uuid"
+
+	"runmate_api/internal/entity"
+	"runmate_api/internal/media"
+	"runmate_api/internal/repository"
+	"runmate_api/internal/storage"
+)
+
+var (
+	ErrMediaNotFound     = errors.New("media not found")
+	ErrMediaLimitReached = errors.New("activity already has the maximum number of photos")
+)
+
+// deleteBlobs removes the files of deleted media. Failures only leave orphan
+// files behind, so they are logged rather than returned.
+func deleteBlobs(ctx context.Context, blobStorage storage.Storage, medias []*entity.ActivityMedia) {
+	for _, m := range medias {
+		for _, key := range []string{m.Key, m.ThumbnailKey} {
+			err := blobStorage.Delete(ctx, key)
+			if err != nil {
+				log.Println("Failed to delete media file:", err)
+			}
+		}
+	}
+}
+
+type Media struct {
+	activityRepo *repository.Activity
+	mediaRepo    *repository.Media
+	storage      storage.Storage
+}
+
+func NewMedia(activityRepo *repository.Activity, mediaRepo *repository.Media, blobStorage storage.Storage) *Media {
+	return &Media{
+		activityRepo: activityRepo,
+		mediaRepo:    mediaRepo,
+		storage:      blobStorage,
+	}
+}
+
+func (m *Media) activity(ctx context.Context, activityID string) (*entity.Activity, error) {
+	activity, err := m.activityRepo.GetByID(ctx, activityID)
+	if err != nil {
+		return nil, err
+	}
+
+	if activity == nil {
+		return nil, ErrActivityNotFound
+	}
+
+	err = authorizeOwner(ctx, activity.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return activity, nil
+}
+
+// Upload attaches the photo to the activity, storing it along with its
+// thumbnail.
+func (m *Media) Upload(ctx context.Context, activityID string, data []byte) (*entity.ActivityMedia, error) {
+	activity, err := m.activity(ctx, activityID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(activity.Media) >= entity.ActivityMediaMaxCount {
+		return nil, ErrMediaLimitReached
+	}
+
+	img, err := media.Process(data)
+	if err != nil {
+		return nil, err
+	}
+
+	id := uuid.New()
+	prefix := "activities/" + activity.ID.String() + "/" + id.String()
+	activityMedia := &entity.ActivityMedia{
+		ID:           id,
+		ActivityID:   activity.ID,
+		UserID:       activity.UserID,
+		Key:          prefix + img.Extension,
+		ThumbnailKey: prefix + "_thumb.jpg",
+		ContentType:  img.ContentType,
+		Size:         len(img.Data),
+		Width:        img.Width,
+		Height:       img.Height,
+	}
+	activityMedia.URL = m.storage.URL(activityMedia.Key)
+	activityMedia.ThumbnailURL = m.storage.URL(activityMedia.ThumbnailKey)
+
+	err = m.storage.Put(ctx, activityMedia.Key, bytes.NewReader(img.Data), int64(len(img.Data)), img.ContentType)
+	if err != nil {
+		return nil, err
+	}
+
+	err = m.storage.Put(ctx, activityMedia.ThumbnailKey, bytes.NewReader(img.Thumbnail), int64(len(img.Thumbnail)), "image/jpeg")
+	if err == nil {
+		err = m.mediaRepo.Create(ctx, activityMedia)
+	}
+
+	if err != nil {
+		deleteBlobs(ctx, m.storage, []*entity.ActivityMedia{activityMedia})
+		return nil, err
+	}
+
+	return activityMedia, nil
+}
+
+// AuthorizeFile allows the caller to read the file stored under key when it
+// belongs to an activity they may see.
+func (m *Media) AuthorizeFile(ctx context.Context, key string) error {
+	viewer, err := caller(ctx)
+	if err != nil {
+		return err
+	}
+
+	activityMedia, err := m.mediaRepo.GetByKey(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	if activityMedia == nil {
+		return ErrMediaNotFound
+	}
+
+	activity, err := m.activityRepo.GetVisibleByID(ctx, activityMedia.ActivityID.String(), viewer.ID)
+	if err != nil {
+		return err
+	}
+
+	if activity == nil {
+		return ErrMediaNotFound
+	}
+
+	return nil
+}
+
+func (m *Media) Delete(ctx context.Context, activityID, mediaID string) error {
+	activity, err := m.activity(ctx, activityID)
+	if err != nil {
+		return err
+	}
+
+	activityMedia, err := m.mediaRepo.GetByID(ctx, mediaID)
+	if err != nil {
+		return err
+	}
+
+	if activityMedia == nil || activityMedia.ActivityID != activity.ID {
+		return ErrMediaNotFound
+	}
+
+	err = m.mediaRepo.Delete(ctx, activityMedia.ID.String())
+	if err != nil {
+		return err
+	}
+
+	deleteBlobs(ctx, m.storage, []*entity.ActivityMedia{activityMedia})
+	return nil
+}
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"runmate_api/internal/entity"
+	"runmate_api/internal/firebase"
+	"runmate_api/internal/repository"
+)
+
+type pendingNotification struct {
+	notification *firebase.Notification
+	tokens       []string
+}
+
+// enqueueNotifications queues the notifications in the outbox, to be sent by
+// the outbox dispatcher once the surrounding transaction commits.
+func enqueueNotifications(ctx context.Context, outboxRepo *repository.Outbox, notifications []*pendingNotification) error {
+	now := time.Now()
+	var outboxNotifications []*entity.OutboxNotification
+	for _, n := range notifications {
+		for _, token := range n.tokens {
+			outboxNotifications = append(outboxNotifications, &entity.OutboxNotification{
+				Title:         n.notification.Title,
+				Body:          n.notification.Body,
+				Token:         token,
+				NextAttemptAt: now,
+			})
+		}
+	}
+
+	return outboxRepo.Create(ctx, outboxNotifications)
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+
+	"runmate_api/internal/entity"
+	"runmate_api/internal/firebase"
+	"runmate_api/internal/geo"
+	"runmate_api/internal/repository"
+)
+
+const personalRecordNotificationTitle = "Novo recorde pessoal! 🏆"
+
+var recordKindNames = map[entity.RecordKind]string{
+	entity.RecordKind1K:           "1 km",
+	entity.RecordKind5K:           "5 km",
+	entity.RecordKind10K:          "10 km",
+	entity.RecordKindHalfMarathon: "meia maratona",
+	entity.RecordKindMarathon:     "maratona",
+	entity.RecordKindLongestRun:   "corrida mais longa",
+}
+
+func formatDuration(seconds int) string {
+	if seconds >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds%3600/60, seconds%60)
+	}
+
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
+
+func personalRecordNotification(activityTitle string, records []*entity.BestEffort) *firebase.Notification {
+	descriptions := make([]string, 0, len(records))
+	for _, record := range records {
+		if record.Kind == entity.RecordKindLongestRun {
+			descriptions = append(descriptions, fmt.Sprintf("%s (%.1f km)", recordKindNames[record.Kind], float64(record.Distance)/1000))
+		} else {
+			descriptions = append(descriptions, fmt.Sprintf("%s (%s)", recordKindNames[record.Kind], formatDuration(record.Duration)))
+		}
+	}
+
+	return &firebase.Notification{
+		Title: personalRecordNotificationTitle,
+		Body:  fmt.Sprintf("Seus novos recordes em %s: %s", activityTitle, strings.Join(descriptions, ", ")),
+	}
+}
+
+// bestEfforts finds the fastest efforts of the activity over each record
+// distance, along with the activity as a candidate for the longest run.
+func bestEfforts(activity *entity.Activity) []*entity.BestEffort {
+	kinds := slices.Sorted(maps.Keys(entity.RecordDistances))
+	distances := make([]float64, 0, len(kinds))
+	for _, kind := range kinds {
+		distances = append(distances, entity.RecordDistances[kind])
+	}
+
+	found := geo.BestEfforts(activity.Coordinates, distances)
+	var efforts []*entity.BestEffort
+	for _, kind := range kinds {
+		seconds, ok := found[entity.RecordDistances[kind]]
+		if !ok {
+			continue
+		}
+
+		efforts = append(efforts, &entity.BestEffort{
+			ActivityID: activity.ID,
+			UserID:     activity.UserID,
+			Kind:       kind,
+			Distance:   int(math.Round(entity.RecordDistances[kind])),
+			Duration:   int(math.Round(seconds)),
+			Date:       activity.Date,
+		})
+	}
+
+	if activity.Distance > 0 {
+		efforts = append(efforts, &entity.BestEffort{
+			ActivityID: activity.ID,
+			UserID:     activity.UserID,
+			Kind:       entity.RecordKindLongestRun,
+			Distance:   activity.Distance,
+			Duration:   activity.Duration,
+			Date:       activity.Date,
+		})
+	}
+
+	return efforts
+}
+
+type Record struct {
+	recordRepo *repository.Record
+	userRepo   *repository.User
+}
+
+func NewRecord(recordRepo *repository.Record, userRepo *repository.User) *Record {
+	return &Record{
+		recordRepo: recordRepo,
+		userRepo:   userRepo,
+	}
+}
+
+// ListByUser returns the personal records of the user set in activities the
+// caller may see.
+func (r *Record) ListByUser(ctx context.Context, userID string) ([]*entity.BestEffort, error) {
+	viewer, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := r.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	return r.recordRepo.GetVisibleByUserID(ctx, user.ID.String(), viewer.ID)
+}
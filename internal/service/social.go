@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"runmate_api/internal/entity"
+	"runmate_api/internal/firebase"
+	"runmate_api/internal/repository"
+)
+
+const (
+	kudosNotificationTitle   = "Você recebeu kudos! 👏"
+	commentNotificationTitle = "Novo comentário 💬"
+	replyNotificationTitle   = "Nova resposta 💬"
+)
+
+var (
+	ErrCommentNotFound = errors.New("comment not found")
+)
+
+func kudosNotification(userName, activityTitle string) *firebase.Notification {
+	return &firebase.Notification{
+		Title: kudosNotificationTitle,
+		Body:  fmt.Sprintf("%s deu kudos em %s", userName, activityTitle),
+	}
+}
+
+func commentNotification(userName, activityTitle string) *firebase.Notification {
+	return &firebase.Notification{
+		Title: commentNotificationTitle,
+		Body:  fmt.Sprintf("%s comentou em %s", userName, activityTitle),
+	}
+}
+
+func replyNotification(userName, activityTitle string) *firebase.Notification {
+	return &firebase.Notification{
+		Title: replyNotificationTitle,
+		Body:  fmt.Sprintf("%s respondeu seu comentário em %s", userName, activityTitle),
+	}
+}
+
+// Social handles the kudos and comments given to activities.
+type Social struct {
+	activityRepo *repository.Activity
+	kudosRepo    *repository.Kudos
+	commentRepo  *repository.Comment
+	outboxRepo   *repository.Outbox
+	transactor   *repository.Transactor
+}
+
+func NewSocial(activityRepo *repository.Activity, kudosRepo *repository.Kudos, commentRepo *repository.Comment, outboxRepo *repository.Outbox, transactor *repository.Transactor) *Social {
+	return &Social{
+		activityRepo: activityRepo,
+		kudosRepo:    kudosRepo,
+		commentRepo:  commentRepo,
+		outboxRepo:   outboxRepo,
+		transactor:   transactor,
+	}
+}
+
+// visibleActivity returns the activity when the caller may see it.
+func (s *Social) visibleActivity(ctx context.Context, activityID string) (*entity.User, *entity.Activity, error) {
+	viewer, err := caller(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	activity, err := s.activityRepo.GetVisibleByID(ctx, activityID, viewer.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if activity == nil {
+		return nil, nil, ErrActivityNotFound
+	}
+
+	return viewer, activity, nil
+}
+
+// notifyUser queues a notification to recipient, unless they are the sender
+// or have no device registered.
+func (s *Social) notifyUser(ctx context.Context, sender, recipient *entity.User, notification *firebase.Notification) error {
+	if recipient == nil || recipient.ID == sender.ID || recipient.FCMToken == "" {
+		return nil
+	}
+
+	return enqueueNotifications(ctx, s.outboxRepo, []*pendingNotification{
+		{notification: notification, tokens: []string{recipient.FCMToken}},
+	})
+}
+
+func (s *Social) ListKudos(ctx context.Context, activityID string) ([]*entity.Kudos, error) {
+	_, activity, err := s.visibleActivity(ctx, activityID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.kudosRepo.GetByActivityID(ctx, activity.ID.String())
+}
+
+// GiveKudos gives kudos to the activity on behalf of the caller. Giving kudos
+// twice has no effect and does not notify the owner again.
+func (s *Social) GiveKudos(ctx context.Context, activityID string) error {
+	viewer, activity, err := s.visibleActivity(ctx, activityID)
+	if err != nil {
+		return err
+	}
+
+	return s.transactor.Do(ctx, func(ctx context.Context) error {
+		created, err := s.kudosRepo.Create(ctx, &entity.Kudos{
+			ActivityID: activity.ID,
+			UserID:     viewer.ID,
+		})
+		if err != nil || !created {
+			return err
+		}
+
+		return s.notifyUser(ctx, viewer, activity.User, kudosNotification(viewer.Name, activity.Title))
+	})
+}
+
+func (s *Social) RemoveKudos(ctx context.Context, activityID string) error {
+	viewer, activity, err := s.visibleActivity(ctx, activityID)
+	if err != nil {
+		return err
+	}
+
+	return s.kudosRepo.Delete(ctx, activity.ID.String(), viewer.ID.String())
+}
+
+func (s *Social) ListComments(ctx context.Context, activityID string) ([]*entity.Comment, error) {
+	_, activity, err := s.visibleActivity(ctx, activityID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.commentRepo.GetByActivityID(ctx, activity.ID.String())
+}
+
+// CreateComment comments on the activity on behalf of the caller, notifying
+// the activity owner and, for replies, the author of the parent comment.
+func (s *Social) CreateComment(ctx context.Context, activityID string, comment *entity.Comment) error {
+	viewer, activity, err := s.visibleActivity(ctx, activityID)
+	if err != nil {
+		return err
+	}
+
+	err = comment.Validate()
+	if err != nil {
+		return err
+	}
+
+	var parent *entity.Comment
+	if comment.ParentID != nil {
+		parent, err = s.commentRepo.GetByID(ctx, comment.ParentID.String())
+		if err != nil {
+			return err
+		}
+
+		if parent == nil || parent.ActivityID != activity.ID {
+			return ErrCommentNotFound
+		}
+	}
+
+	comment.ActivityID = activity.ID
+	comment.UserID = viewer.ID
+	return s.transactor.Do(ctx, func(ctx context.Context) error {
+		err := s.commentRepo.Create(ctx, comment)
+		if err != nil {
+			return err
+		}
+
+		err = s.notifyUser(ctx, viewer, activity.User, commentNotification(viewer.Name, activity.Title))
+		if err != nil {
+			return err
+		}
+
+		if parent == nil || parent.UserID == activity.UserID {
+			return nil
+		}
+
+		return s.notifyUser(ctx, viewer, parent.User, replyNotification(viewer.Name, activity.Title))
+	})
+}
+
+// DeleteComment deletes the comment and its replies. Only the comment author
+// and the activity owner may delete it.
+func (s *Social) DeleteComment(ctx context.Context, activityID, commentID string) error {
+	_, activity, err := s.visibleActivity(ctx, activityID)
+	if err != nil {
+		return err
+	}
+
+	comment, err := s.commentRepo.GetByID(ctx, commentID)
+	if err != nil {
+		return err
+	}
+
+	if comment == nil || comment.ActivityID != activity.ID {
+		return ErrCommentNotFound
+	}
+
+	if authorizeOwner(ctx, comment.UserID) != nil {
+		err = authorizeOwner(ctx, activity.UserID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.commentRepo.Delete(ctx, comment.ID.String())
+}
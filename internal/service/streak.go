@@ -0,0 +1,335 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"runmate_api/internal/entity"
+	"runmate_api/internal/firebase"
+	"runmate_api/internal/repository"
+)
+
+const (
+	streakAtRiskNotificationTitle = "Sua sequência está em risco 🔥"
+
+	// streakReminderHour is the local hour from which users are warned that
+	// their daily streak ends today.
+	streakReminderHour = 20
+	// streakCheckWeeks is how many weeks back users must have run to have
+	// their streaks checked, longer than freezes may keep a streak running.
+	streakCheckWeeks = entity.StreakFreezeMax + 2
+)
+
+func streakAtRiskNotification(days, freezes int) *firebase.Notification {
+	streak := fmt.Sprintf("%d dias", days)
+	if days == 1 {
+		streak = "1 dia"
+	}
+
+	body := fmt.Sprintf("Sua sequência de %s de corrida termina hoje. Corra antes da meia-noite para mantê-la!", streak)
+	if freezes > 0 {
+		body = fmt.Sprintf("Sua sequência de %s de corrida termina hoje. Corra antes da meia-noite para não gastar um bloqueio de sequência!", streak)
+	}
+
+	return &firebase.Notification{
+		Title: streakAtRiskNotificationTitle,
+		Body:  body,
+	}
+}
+
+// earnStreakFreezes grants a streak freeze for each StreakFreezeXP the user
+// reaches for the first time, so XP taken back and earned again grants none.
+func earnStreakFreezes(user *entity.User) {
+	earned := user.XP / entity.StreakFreezeXP
+	if earned <= user.FreezesEarned {
+		return
+	}
+
+	user.StreakFreezes = min(user.StreakFreezes+earned-user.FreezesEarned, entity.StreakFreezeMax)
+	user.FreezesEarned = earned
+}
+
+// refundStreakFreezes gives the owner back the freezes spent on the day and
+// week of the run, which no longer need them.
+func refundStreakFreezes(ctx context.Context, streakRepo *repository.Streak, owner *entity.User, activity *entity.Activity) error {
+	periods := map[entity.StreakKind]time.Time{
+		entity.StreakKindDaily:  owner.StartOfDay(activity.Date),
+		entity.StreakKindWeekly: owner.StartOfWeek(activity.Date),
+	}
+
+	for kind, start := range periods {
+		refunded, err := streakRepo.DeleteFreeze(ctx, &entity.StreakFreeze{
+			UserID: owner.ID,
+			Kind:   kind,
+			Start:  start.Format("2006-01-02"),
+		})
+		if err != nil {
+			return err
+		}
+
+		if refunded {
+			owner.StreakFreezes = min(owner.StreakFreezes+1, entity.StreakFreezeMax)
+		}
+	}
+
+	return nil
+}
+
+// streakHistory holds, by the Unix time they start at, the days and weeks in
+// which the user ran or spent a streak freeze.
+type streakHistory struct {
+	user   *entity.User
+	first  time.Time
+	ran    map[entity.StreakKind]map[int64]bool
+	frozen map[entity.StreakKind]map[int64]bool
+}
+
+// loadStreakHistory loads the streak history of the user, out of the
+// activities the viewer may see, from from or, when nil, since their first run.
+func loadStreakHistory(ctx context.Context, activityRepo *repository.Activity, streakRepo *repository.Streak, user *entity.User, viewerID uuid.UUID, from *time.Time) (*streakHistory, error) {
+	days, err := activityRepo.GetTotals(ctx, user.ID.String(), viewerID, &entity.TotalsFilter{
+		Period:   entity.TotalsPeriodDay,
+		Location: user.Location(),
+		From:     from,
+		Types:    entity.StreakActivityTypes,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var fromDay string
+	if from != nil {
+		fromDay = user.StartOfDay(*from).Format("2006-01-02")
+	}
+
+	freezes, err := streakRepo.GetFreezesByUserID(ctx, user.ID.String(), fromDay)
+	if err != nil {
+		return nil, err
+	}
+
+	history := &streakHistory{
+		user: user,
+		ran: map[entity.StreakKind]map[int64]bool{
+			entity.StreakKindDaily:  {},
+			entity.StreakKindWeekly: {},
+		},
+		frozen: map[entity.StreakKind]map[int64]bool{
+			entity.StreakKindDaily:  {},
+			entity.StreakKindWeekly: {},
+		},
+	}
+
+	for _, day := range days {
+		history.ran[entity.StreakKindDaily][day.Start.Unix()] = true
+		history.ran[entity.StreakKindWeekly][user.StartOfWeek(day.Start).Unix()] = true
+		history.see(day.Start)
+	}
+
+	for _, freeze := range freezes {
+		start, err := time.ParseInLocation("2006-01-02", freeze.Start, user.Location())
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse streak freeze start: %v", err)
+		}
+
+		history.frozen[freeze.Kind][start.Unix()] = true
+		history.see(start)
+	}
+
+	return history, nil
+}
+
+func (h *streakHistory) see(day time.Time) {
+	if h.first.IsZero() || day.Before(h.first) {
+		h.first = day
+	}
+}
+
+func (h *streakHistory) start(kind entity.StreakKind, t time.Time) time.Time {
+	if kind == entity.StreakKindWeekly {
+		return h.user.StartOfWeek(t)
+	}
+
+	return h.user.StartOfDay(t)
+}
+
+func (h *streakHistory) next(kind entity.StreakKind, period time.Time) time.Time {
+	if kind == entity.StreakKindWeekly {
+		return nextWeek(period)
+	}
+
+	return nextDay(period)
+}
+
+func (h *streakHistory) previous(kind entity.StreakKind, period time.Time) time.Time {
+	if kind == entity.StreakKindWeekly {
+		return period.AddDate(0, 0, -weekdays)
+	}
+
+	return period.AddDate(0, 0, -1)
+}
+
+// kept tells whether the user ran or spent a freeze in the period.
+func (h *streakHistory) kept(kind entity.StreakKind, period time.Time) bool {
+	return h.ran[kind][period.Unix()] || h.frozen[kind][period.Unix()]
+}
+
+// streak counts the streak of the kind until the period of now.
+func (h *streakHistory) streak(kind entity.StreakKind, now time.Time) *entity.Streak {
+	streak := &entity.Streak{}
+	if h.first.IsZero() {
+		return streak
+	}
+
+	current := h.start(kind, now)
+	for period := h.start(kind, h.first); !period.After(current); period = h.next(kind, period) {
+		switch {
+		case h.ran[kind][period.Unix()]:
+			streak.Current++
+			streak.Longest = max(streak.Longest, streak.Current)
+		case h.frozen[kind][period.Unix()], period.Equal(current):
+		default:
+			streak.Current = 0
+		}
+	}
+
+	return streak
+}
+
+type Streak struct {
+	activityRepo *repository.Activity
+	reminderRepo *repository.Reminder
+	streakRepo   *repository.Streak
+	userRepo     *repository.User
+	outboxRepo   *repository.Outbox
+	transactor   *repository.Transactor
+}
+
+func NewStreak(activityRepo *repository.Activity, reminderRepo *repository.Reminder, streakRepo *repository.Streak, userRepo *repository.User, outboxRepo *repository.Outbox, transactor *repository.Transactor) *Streak {
+	return &Streak{
+		activityRepo: activityRepo,
+		reminderRepo: reminderRepo,
+		streakRepo:   streakRepo,
+		userRepo:     userRepo,
+		outboxRepo:   outboxRepo,
+		transactor:   transactor,
+	}
+}
+
+// Check spends streak freezes on the day and week the runners just missed,
+// when that would break their streaks, and warns in the evening the ones whose
+// daily streak ends today unless they run.
+func (s *Streak) Check(ctx context.Context, now time.Time) error {
+	users, err := s.userRepo.GetAllActiveSince(ctx, now.AddDate(0, 0, -streakCheckWeeks*weekdays), entity.StreakActivityTypes)
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		err = s.check(ctx, user, now)
+		if err != nil {
+			log.Printf("Failed to check streaks of user %s: %v\n", user.ID, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Streak) check(ctx context.Context, user *entity.User, now time.Time) error {
+	// The week before last is the earliest period telling whether a streak
+	// was running.
+	from := user.StartOfWeek(now).AddDate(0, 0, -2*weekdays)
+	history, err := loadStreakHistory(ctx, s.activityRepo, s.streakRepo, user, user.ID, &from)
+	if err != nil {
+		return err
+	}
+
+	today := user.StartOfDay(now)
+	yesterday := history.previous(entity.StreakKindDaily, today)
+	err = s.freeze(ctx, user, history, entity.StreakKindDaily, yesterday)
+	if err != nil {
+		return err
+	}
+
+	lastWeek := history.previous(entity.StreakKindWeekly, user.StartOfWeek(now))
+	err = s.freeze(ctx, user, history, entity.StreakKindWeekly, lastWeek)
+	if err != nil {
+		return err
+	}
+
+	if user.FCMToken == "" || now.In(user.Location()).Hour() < streakReminderHour {
+		return nil
+	}
+
+	if history.ran[entity.StreakKindDaily][today.Unix()] || !history.kept(entity.StreakKindDaily, yesterday) {
+		return nil
+	}
+
+	history, err = loadStreakHistory(ctx, s.activityRepo, s.streakRepo, user, user.ID, nil)
+	if err != nil {
+		return err
+	}
+
+	streak := history.streak(entity.StreakKindDaily, now)
+	if streak.Current == 0 {
+		return nil
+	}
+
+	return s.transactor.Do(ctx, func(ctx context.Context) error {
+		created, err := s.reminderRepo.Create(ctx, &entity.Reminder{
+			UserID: user.ID,
+			Kind:   entity.ReminderKindStreakAtRisk,
+			Day:    today.Format("2006-01-02"),
+		})
+		if err != nil || !created {
+			return err
+		}
+
+		return enqueueNotifications(ctx, s.outboxRepo, []*pendingNotification{{
+			notification: streakAtRiskNotification(streak.Current, user.StreakFreezes),
+			tokens:       []string{user.FCMToken},
+		}})
+	})
+}
+
+// freeze spends a streak freeze of the user on the missed period when the
+// period before it kept a streak running.
+func (s *Streak) freeze(ctx context.Context, user *entity.User, history *streakHistory, kind entity.StreakKind, period time.Time) error {
+	if user.StreakFreezes == 0 || history.kept(kind, period) || !history.kept(kind, history.previous(kind, period)) {
+		return nil
+	}
+
+	var spent bool
+	err := s.transactor.Do(ctx, func(ctx context.Context) error {
+		var err error
+		spent, err = s.userRepo.SpendStreakFreeze(ctx, user.ID)
+		if err != nil || !spent {
+			return err
+		}
+
+		created, err := s.streakRepo.CreateFreeze(ctx, &entity.StreakFreeze{
+			UserID: user.ID,
+			Kind:   kind,
+			Start:  period.Format("2006-01-02"),
+		})
+		if err != nil {
+			return err
+		}
+
+		if !created {
+			return fmt.Errorf("streak already frozen on %s", period.Format("2006-01-02"))
+		}
+
+		return nil
+	})
+	if err != nil || !spent {
+		return err
+	}
+
+	user.StreakFreezes--
+	history.frozen[kind][period.Unix()] = true
+	return nil
+}
@@ -3,71 +3,228 @@ package service
 import (
 	"context"
 	"errors"
+	"log"
+	"slices"
+	"sync"
 	"time"
 
+	"github.com/google/uuid"
+
+	"runmate_api/internal/auth"
 	"runmate_api/internal/entity"
 	"runmate_api/internal/repository"
 )
 
-const weekdays = 7
+const (
+	weekdays = 7
+
+	// Stats cover this many of the latest weeks and months.
+	statsWeeks  = 12
+	statsMonths = 12
+)
 
 var (
-	ErrUserNotFound = errors.New("user not found")
+	ErrUserNotFound       = errors.New("user not found")
+	ErrInvalidCredentials = errors.New("invalid credentials")
 )
 
 type User struct {
-	activityRepo *repository.Activity
-	userRepo     *repository.User
+	activityRepo    *repository.Activity
+	goalRepo        *repository.Goal
+	privacyZoneRepo *repository.PrivacyZone
+	streakRepo      *repository.Streak
+	userRepo        *repository.User
+
+	tokenManager *auth.TokenManager
+	levelRewards entity.LevelRewards
 }
 
-func NewUser(activityRepo *repository.Activity, userRepo *repository.User) *User {
-	return &User{activityRepo: activityRepo, userRepo: userRepo}
-}
+func NewUser(activityRepo *repository.Activity, goalRepo *repository.Goal, privacyZoneRepo *repository.PrivacyZone, streakRepo *repository.Streak, userRepo *repository.User, tokenManager *auth.TokenManager, levelRewards entity.LevelRewards) *User {
+	return &User{
+		activityRepo:    activityRepo,
+		goalRepo:        goalRepo,
+		privacyZoneRepo: privacyZoneRepo,
+		streakRepo:      streakRepo,
+		userRepo:        userRepo,
 
-func (u *User) enrichUserWithWeekActivities(ctx context.Context, user *entity.User) error {
-	today := time.Now()
-	todayWithoutHour := today.Truncate(24 * time.Hour)
-	offset := (int(time.Sunday) - int(todayWithoutHour.Weekday()) - weekdays) % weekdays
-	start := todayWithoutHour.Add(time.Duration(offset*24) * time.Hour)
-	end := start.AddDate(0, 0, weekdays)
+		tokenManager: tokenManager,
+		levelRewards: levelRewards,
+	}
+}
 
-	activities, err := u.activityRepo.GetByUserIDAndDateRange(ctx, user.ID.String(), start, today)
-	if err != nil {
-		return err
+// fillTotals lists the count periods from start, each starting where next
+// moves the previous one, with zeroed totals for the periods without
+// activities.
+func fillTotals(totals []*entity.ActivityTotals, start time.Time, count int, next func(time.Time) time.Time) []*entity.ActivityTotals {
+	totalsByStart := make(map[int64]*entity.ActivityTotals, len(totals))
+	for _, t := range totals {
+		totalsByStart[t.Start.Unix()] = t
 	}
 
-	weekActivitiesMap := make(map[string]*entity.UserDayActitivy, weekdays)
-	for _, activity := range activities {
-		dateKey := activity.Date.Format("2006-01-02")
-		if day, ok := weekActivitiesMap[dateKey]; ok {
-			day.Distance += activity.Distance
+	filled := make([]*entity.ActivityTotals, 0, count)
+	for period := start; len(filled) < count; period = next(period) {
+		if t, ok := totalsByStart[period.Unix()]; ok {
+			filled = append(filled, t)
 		} else {
-			weekActivitiesMap[dateKey] = &entity.UserDayActitivy{
-				Date:     activity.Date,
-				Distance: activity.Distance,
-			}
+			filled = append(filled, &entity.ActivityTotals{Start: period})
 		}
 	}
 
+	return filled
+}
+
+func nextDay(t time.Time) time.Time {
+	return t.AddDate(0, 0, 1)
+}
+
+func nextWeek(t time.Time) time.Time {
+	return t.AddDate(0, 0, weekdays)
+}
+
+func nextMonth(t time.Time) time.Time {
+	return t.AddDate(0, 1, 0)
+}
+
+func nextYear(t time.Time) time.Time {
+	return t.AddDate(1, 0, 0)
+}
+
+// enrichUserWithWeekActivities fills the distance of each day of the current
+// week, the active goals of the user, with their progress, their streaks and
+// the profile frames they unlocked. Only the activities the viewer may see
+// count.
+func (u *User) enrichUserWithWeekActivities(ctx context.Context, user *entity.User, viewerID uuid.UUID) error {
+	now := time.Now()
+	start := user.StartOfWeek(now)
+	days, err := u.activityRepo.GetTotals(ctx, user.ID.String(), viewerID, &entity.TotalsFilter{
+		Period:   entity.TotalsPeriodDay,
+		Location: user.Location(),
+		From:     &start,
+	})
+	if err != nil {
+		return err
+	}
+
 	weekActivities := make([]*entity.UserDayActitivy, 0, weekdays)
-	for i := start; i.Before(end); i = i.AddDate(0, 0, 1) {
-		dateKey := i.Format("2006-01-02")
-		if day, ok := weekActivitiesMap[dateKey]; ok {
-			weekActivities = append(weekActivities, day)
-		} else {
-			weekActivities = append(weekActivities, &entity.UserDayActitivy{Date: i})
-		}
+	for _, day := range fillTotals(days, start, weekdays, nextDay) {
+		weekActivities = append(weekActivities, &entity.UserDayActitivy{
+			Date:     day.Start,
+			Distance: day.Distance,
+		})
+	}
+
+	goals, err := u.goalRepo.GetByUserID(ctx, user.ID.String(), false)
+	if err != nil {
+		return err
+	}
+
+	err = evaluateGoals(ctx, u.activityRepo, user, viewerID, goals, now)
+	if err != nil {
+		return err
+	}
+
+	history, err := loadStreakHistory(ctx, u.activityRepo, u.streakRepo, user, viewerID, nil)
+	if err != nil {
+		return err
 	}
 
 	user.WeekActivities = weekActivities
+	user.Goals = goals
+	user.DailyStreak = history.streak(entity.StreakKindDaily, now)
+	user.WeeklyStreak = history.streak(entity.StreakKindWeekly, now)
+	user.ProfileFrames = u.levelRewards.ProfileFrames(user.HighestLevel())
 	return nil
 }
 
+// Stats sums the activities of the user the caller may see over the last
+// weeks and months, by year and overall. Periods follow timeZone or, when
+// empty, the time zone of the user.
+func (u *User) Stats(ctx context.Context, userID, timeZone string, types []entity.ActivityType) (*entity.UserStats, error) {
+	viewer, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := u.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	if timeZone != "" {
+		if _, err := time.LoadLocation(timeZone); err != nil {
+			return nil, entity.ErrInvalidTimeZone
+		}
+
+		user.TimeZone = timeZone
+	}
+
+	now := time.Now()
+	weeksStart := user.StartOfWeek(now).AddDate(0, 0, -weekdays*(statsWeeks-1))
+	today := user.StartOfDay(now)
+	monthsStart := time.Date(today.Year(), today.Month()-(statsMonths-1), 1, 0, 0, 0, 0, today.Location())
+
+	totals := func(period entity.TotalsPeriod, from *time.Time) ([]*entity.ActivityTotals, error) {
+		return u.activityRepo.GetTotals(ctx, user.ID.String(), viewer.ID, &entity.TotalsFilter{
+			Period:    period,
+			Location:  user.Location(),
+			WeekStart: user.WeekStart,
+			From:      from,
+			Types:     types,
+		})
+	}
+
+	weeks, err := totals(entity.TotalsPeriodWeek, &weeksStart)
+	if err != nil {
+		return nil, err
+	}
+
+	months, err := totals(entity.TotalsPeriodMonth, &monthsStart)
+	if err != nil {
+		return nil, err
+	}
+
+	years, err := totals(entity.TotalsPeriodYear, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	allTime, err := totals(entity.TotalsPeriodAll, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &entity.UserStats{
+		TimeZone:  user.TimeZone,
+		WeekStart: user.WeekStart,
+		Weeks:     fillTotals(weeks, weeksStart, statsWeeks, nextWeek),
+		Months:    fillTotals(months, monthsStart, statsMonths, nextMonth),
+		AllTime:   &entity.ActivityTotals{},
+	}
+
+	if len(years) > 0 {
+		stats.Years = fillTotals(years, years[0].Start, today.Year()-years[0].Start.Year()+1, nextYear)
+	}
+
+	if len(allTime) > 0 {
+		stats.AllTime = allTime[0]
+	}
+
+	return stats, nil
+}
+
 func (u *User) Create(ctx context.Context, user *entity.User) error {
 	if err := user.Validate(); err != nil {
 		return err
 	}
 
+	if err := user.SetPassword(user.Password); err != nil {
+		return err
+	}
+
 	return u.userRepo.Create(ctx, user)
 }
 
@@ -89,12 +246,27 @@ func (u *User) ListAllNonFriends(ctx context.Context, userID string) ([]*entity.
 }
 
 func (u *User) GetByID(ctx context.Context, id string) (*entity.User, error) {
+	return u.userRepo.GetByID(ctx, id)
+}
+
+// GetProfile returns the user with their week, goals and streaks as the
+// caller may see them.
+func (u *User) GetProfile(ctx context.Context, id string) (*entity.User, error) {
+	viewer, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	user, err := u.userRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	err = u.enrichUserWithWeekActivities(ctx, user)
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	err = u.enrichUserWithWeekActivities(ctx, user, viewer.ID)
 	if err != nil {
 		return nil, err
 	}
@@ -106,7 +278,14 @@ func (u *User) GetByUsername(ctx context.Context, username string) (*entity.User
 	return u.userRepo.GetByUsername(ctx, username)
 }
 
-func (u *User) Update(ctx context.Context, user *entity.User) error {
+// Update saves the profile of the user. An empty password or time zone, or a
+// nil week start, keeps the current one.
+func (u *User) Update(ctx context.Context, user *entity.User, weekStart *time.Weekday) error {
+	err := authorizeOwner(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
 	currentUser, err := u.GetByID(ctx, user.ID.String())
 	if err != nil {
 		return err
@@ -116,14 +295,38 @@ func (u *User) Update(ctx context.Context, user *entity.User) error {
 		return ErrUserNotFound
 	}
 
+	if user.TimeZone == "" {
+		user.TimeZone = currentUser.TimeZone
+	}
+
+	user.WeekStart = currentUser.WeekStart
+	if weekStart != nil {
+		user.WeekStart = *weekStart
+	}
+
 	if err := user.Validate(); err != nil {
 		return err
 	}
 
+	if user.Password == "" {
+		user.Password = currentUser.Password
+	} else if err := user.SetPassword(user.Password); err != nil {
+		return err
+	}
+
+	user.Role = currentUser.Role
+	user.ProfileFrame = currentUser.ProfileFrame
+	user.FCMToken = currentUser.FCMToken
+	user.CreatedAt = currentUser.CreatedAt
 	return u.userRepo.Update(ctx, user)
 }
 
 func (u *User) UpdateFCMToken(ctx context.Context, userID string, fcmToken string) error {
+	err := authorizeUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
 	user, err := u.GetByID(ctx, userID)
 	if err != nil {
 		return err
@@ -137,8 +340,15 @@ func (u *User) UpdateFCMToken(ctx context.Context, userID string, fcmToken strin
 	return u.userRepo.Update(ctx, user)
 }
 
-func (u *User) UpdateGoal(ctx context.Context, userID string, days, distance *int) error {
-	user, err := u.GetByID(ctx, userID)
+// UpdateProfileFrame shows the frame, among the ones the levels of the user
+// unlocked, around their profile. An empty frame removes it.
+func (u *User) UpdateProfileFrame(ctx context.Context, userID string, frame string) error {
+	err := authorizeUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	user, err := u.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		return err
 	}
@@ -147,15 +357,36 @@ func (u *User) UpdateGoal(ctx context.Context, userID string, days, distance *in
 		return ErrUserNotFound
 	}
 
-	user.GoalDays = days
-	user.GoalDailyDistance = distance
+	if frame != "" && !slices.Contains(u.levelRewards.ProfileFrames(user.HighestLevel()), frame) {
+		return entity.ErrInvalidProfileFrame
+	}
+
+	user.ProfileFrame = frame
 	return u.userRepo.Update(ctx, user)
 }
 
 func (u *User) Delete(ctx context.Context, id string) error {
+	err := authorizeUser(ctx, id)
+	if err != nil {
+		return err
+	}
+
 	return u.userRepo.Delete(ctx, id)
 }
 
+// unknownUser stands in for usernames that do not exist, so that checking
+// their password takes as long as for a real user and does not reveal which
+// usernames are taken.
+var unknownUser = sync.OnceValue(func() *entity.User {
+	user := &entity.User{}
+	err := user.SetPassword(uuid.NewString())
+	if err != nil {
+		log.Printf("Failed to hash the password of the unknown user: %v\n", err)
+	}
+
+	return user
+})
+
 func (u *User) Authenticate(ctx context.Context, username, password string) (*entity.User, error) {
 	user, err := u.GetByUsername(ctx, username)
 	if err != nil {
@@ -163,17 +394,81 @@ func (u *User) Authenticate(ctx context.Context, username, password string) (*en
 	}
 
 	if user == nil {
+		unknownUser().CheckPassword(password)
 		return nil, nil
 	}
 
-	if user.Password != password {
+	if !user.CheckPassword(password) {
 		return nil, nil
 	}
 
+	if !user.HasHashedPassword() {
+		err = user.SetPassword(password)
+		if err != nil {
+			return nil, err
+		}
+
+		err = u.userRepo.Update(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return user, nil
+}
+
+func (u *User) Login(ctx context.Context, username, password string) (*entity.User, *auth.Tokens, error) {
+	user, err := u.Authenticate(ctx, username, password)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if user == nil {
+		return nil, nil, ErrInvalidCredentials
+	}
+
+	tokens, err := u.tokenManager.Issue(user)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return user, tokens, nil
+}
+
+func (u *User) RefreshTokens(ctx context.Context, refreshToken string) (*auth.Tokens, error) {
+	claims, err := u.tokenManager.Parse(refreshToken, auth.TokenTypeRefresh)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := u.userRepo.GetByID(ctx, claims.Subject)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	return u.tokenManager.Issue(user)
+}
+
+func (u *User) GetByAccessToken(ctx context.Context, accessToken string) (*entity.User, error) {
+	claims, err := u.tokenManager.Parse(accessToken, auth.TokenTypeAccess)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := u.userRepo.GetByID(ctx, claims.Subject)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
 	return user, nil
 }
 
 func (u *User) AddFriend(ctx context.Context, userID, friendID string) error {
+	err := authorizeUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
 	user, err := u.GetByID(ctx, userID)
 	if err != nil {
 		return err
@@ -213,6 +508,11 @@ func (u *User) ListFriends(ctx context.Context, userID string) ([]*entity.User,
 }
 
 func (u *User) RemoveFriend(ctx context.Context, userID, friendID string) error {
+	err := authorizeUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
 	user, err := u.GetByID(ctx, userID)
 	if err != nil {
 		return err
@@ -233,3 +533,35 @@ func (u *User) RemoveFriend(ctx context.Context, userID, friendID string) error
 
 	return u.userRepo.DeleteFriend(ctx, user, friend)
 }
+
+func (u *User) ListPrivacyZones(ctx context.Context, userID string) ([]*entity.PrivacyZone, error) {
+	err := authorizeUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.privacyZoneRepo.GetByUserID(ctx, userID)
+}
+
+func (u *User) CreatePrivacyZone(ctx context.Context, zone *entity.PrivacyZone) error {
+	err := authorizeOwner(ctx, zone.UserID)
+	if err != nil {
+		return err
+	}
+
+	err = zone.Validate()
+	if err != nil {
+		return err
+	}
+
+	return u.privacyZoneRepo.Create(ctx, zone)
+}
+
+func (u *User) DeletePrivacyZone(ctx context.Context, userID, zoneID string) error {
+	err := authorizeUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	return u.privacyZoneRepo.Delete(ctx, userID, zoneID)
+}
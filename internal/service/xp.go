@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"runmate_api/internal/entity"
+	"runmate_api/internal/repository"
+)
+
+// addXP records the transaction in the ledger of the user and updates the XP
+// they cache, along with the streak freezes and levels it earns, placed in the
+// feed at date. Callers save the user and queue the returned notifications.
+func addXP(ctx context.Context, levelUpRepo *repository.LevelUp, xpRepo *repository.XP, rewards entity.LevelRewards, user *entity.User, transaction *entity.XPTransaction, date time.Time) ([]*pendingNotification, error) {
+	if transaction.Amount == 0 {
+		return nil, nil
+	}
+
+	transaction.UserID = user.ID
+	err := xpRepo.Create(ctx, transaction)
+	if err != nil {
+		return nil, err
+	}
+
+	reached := user.HighestLevel()
+	user.XP += transaction.Amount
+	earnStreakFreezes(user)
+	return levelUp(ctx, levelUpRepo, xpRepo, rewards, user, reached, date)
+}
+
+type XP struct {
+	levelUpRepo *repository.LevelUp
+	userRepo    *repository.User
+	xpRepo      *repository.XP
+	outboxRepo  *repository.Outbox
+	transactor  *repository.Transactor
+
+	levelRewards entity.LevelRewards
+}
+
+func NewXP(levelUpRepo *repository.LevelUp, userRepo *repository.User, xpRepo *repository.XP, outboxRepo *repository.Outbox, transactor *repository.Transactor, levelRewards entity.LevelRewards) *XP {
+	return &XP{
+		levelUpRepo: levelUpRepo,
+		userRepo:    userRepo,
+		xpRepo:      xpRepo,
+		outboxRepo:  outboxRepo,
+		transactor:  transactor,
+
+		levelRewards: levelRewards,
+	}
+}
+
+// GetLedger returns the user with their XP ledger.
+func (x *XP) GetLedger(ctx context.Context, userID string) (*entity.User, error) {
+	err := authorizeUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := x.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	user.XPTransactions, err = x.xpRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// Adjust adds amount, which may be negative but not below the XP of the user,
+// to the XP of the user, explained by reason.
+func (x *XP) Adjust(ctx context.Context, userID string, amount int, reason string) (*entity.XPTransaction, error) {
+	err := AuthorizeAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	admin, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reason = strings.TrimSpace(reason)
+	if amount == 0 || reason == "" {
+		return nil, entity.ErrInvalidXPAdjustment
+	}
+
+	transaction := &entity.XPTransaction{
+		Source:    entity.XPSourceAdminAdjustment,
+		Amount:    amount,
+		Reason:    reason,
+		CreatedBy: &admin.ID,
+	}
+
+	err = x.transactor.Do(ctx, func(ctx context.Context) error {
+		user, err := x.userRepo.GetByIDForUpdate(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		if user == nil {
+			return ErrUserNotFound
+		}
+
+		if user.XP+amount < 0 {
+			return entity.ErrInvalidXPAdjustment
+		}
+
+		notifications, err := addXP(ctx, x.levelUpRepo, x.xpRepo, x.levelRewards, user, transaction, time.Now())
+		if err != nil {
+			return err
+		}
+
+		err = x.userRepo.UpdateProgress(ctx, user)
+		if err != nil {
+			return err
+		}
+
+		return enqueueNotifications(ctx, x.outboxRepo, notifications)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return transaction, nil
+}
@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Local stores the blobs in a directory, meant for development and tests. The
+// directory is served by the API under baseURL.
+type Local struct {
+	dir     string
+	baseURL string
+}
+
+func NewLocal(dir, baseURL string) (*Local, error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create storage directory %s: %v", dir, err)
+	}
+
+	return &Local{
+		dir:     dir,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}, nil
+}
+
+func (l *Local) path(key string) string {
+	return filepath.Join(l.dir, filepath.FromSlash(filepath.Clean("/"+key)))
+}
+
+func (l *Local) Put(_ context.Context, key string, body io.Reader, _ int64, _ string) error {
+	path := l.path(key)
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return fmt.Errorf("failed to create directory for %s: %v", key, err)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create file %s: %v", key, err)
+	}
+	defer file.Close()
+
+	_, err = io.Copy(file, body)
+	if err != nil {
+		return fmt.Errorf("failed to write file %s: %v", key, err)
+	}
+
+	return nil
+}
+
+func (l *Local) Delete(_ context.Context, key string) error {
+	err := os.Remove(l.path(key))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete file %s: %v", key, err)
+	}
+
+	return nil
+}
+
+func (l *Local) URL(key string) string {
+	return l.baseURL + "/" + (&url.URL{Path: key}).EscapedPath()
+}
+
+// Handler serves the stored files, without listing the directories.
+func (l *Local) Handler() http.Handler {
+	files := http.FileServer(http.Dir(l.dir))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/") {
+			http.NotFound(w, r)
+			return
+		}
+
+		files.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,215 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	s3Service         = "s3"
+	s3Algorithm       = "AWS4-HMAC-SHA256"
+	s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+	s3DateFormat      = "20060102T150405Z"
+)
+
+type S3Config struct {
+	// Endpoint of the S3-compatible API, like https://s3.us-east-1.amazonaws.com
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// PublicURL is where the bucket objects are served from, like a CDN.
+	// Defaults to the bucket address at the endpoint.
+	PublicURL string
+}
+
+// S3 stores the blobs in a bucket of an S3-compatible service, addressed by
+// path and authenticated with AWS Signature Version 4.
+type S3 struct {
+	config S3Config
+	client *http.Client
+}
+
+func NewS3(config S3Config) *S3 {
+	config.Endpoint = strings.TrimSuffix(config.Endpoint, "/")
+	if config.PublicURL == "" {
+		config.PublicURL = config.Endpoint + "/" + config.Bucket
+	}
+	config.PublicURL = strings.TrimSuffix(config.PublicURL, "/")
+
+	return &S3{
+		config: config,
+		client: &http.Client{Timeout: time.Minute},
+	}
+}
+
+func (s *S3) objectURL(key string) string {
+	return s.config.Endpoint + "/" + s.config.Bucket + "/" + (&url.URL{Path: key}).EscapedPath()
+}
+
+func (s *S3) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key), body)
+	if err != nil {
+		return fmt.Errorf("failed to create request for %s: %v", key, err)
+	}
+
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+	return s.do(req)
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(key), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request for %s: %v", key, err)
+	}
+
+	return s.do(req)
+}
+
+func (s *S3) URL(key string) string {
+	return s.config.PublicURL + "/" + (&url.URL{Path: key}).EscapedPath()
+}
+
+func (s *S3) do(req *http.Request) error {
+	s.sign(req, time.Now().UTC())
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to %s %s: %v", req.Method, req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		return fmt.Errorf("failed to %s %s: %s: %s", req.Method, req.URL.Path, resp.Status, message)
+	}
+
+	return nil
+}
+
+// sign adds the Signature Version 4 authorization to the request, signing
+// every header it carries. The payload is left unsigned so the body can be
+// streamed, unless the caller already set its hash.
+func (s *S3) sign(req *http.Request, now time.Time) {
+	timestamp := now.Format(s3DateFormat)
+	date := timestamp[:8]
+	req.Header.Set("X-Amz-Date", timestamp)
+	payloadHash := req.Header.Get("X-Amz-Content-Sha256")
+	if payloadHash == "" {
+		payloadHash = s3UnsignedPayload
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		if name == "Authorization" {
+			continue
+		}
+
+		trimmed := make([]string, 0, len(values))
+		for _, value := range values {
+			trimmed = append(trimmed, strings.Join(strings.Fields(value), " "))
+		}
+		headers[strings.ToLower(name)] = strings.Join(trimmed, ",")
+	}
+
+	signedHeaders := slices.Sorted(maps.Keys(headers))
+	var canonicalHeaders strings.Builder
+	for _, header := range signedHeaders {
+		canonicalHeaders.WriteString(header + ":" + headers[header] + "\n")
+	}
+
+	path := req.URL.Path
+	if path == "" {
+		path = "/"
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s3Escape(path, true),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		strings.Join(signedHeaders, ";"),
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.config.Region + "/" + s3Service + "/aws4_request"
+	stringToSign := strings.Join([]string{
+		s3Algorithm,
+		timestamp,
+		scope,
+		hex.EncodeToString(sha256Sum([]byte(canonicalRequest))),
+	}, "\n")
+
+	key := hmacSum([]byte("AWS4"+s.config.SecretAccessKey), date)
+	key = hmacSum(key, s.config.Region)
+	key = hmacSum(key, s3Service)
+	key = hmacSum(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSum(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, s.config.AccessKeyID, scope, strings.Join(signedHeaders, ";"), signature,
+	))
+}
+
+// canonicalQuery sorts the query parameters by name, then value, each escaped
+// and joined as name=value, even when the value is empty.
+func canonicalQuery(query url.Values) string {
+	escaped := make(map[string][]string, len(query))
+	for name, values := range query {
+		name = s3Escape(name, false)
+		for _, value := range values {
+			escaped[name] = append(escaped[name], s3Escape(value, false))
+		}
+	}
+
+	var params []string
+	for _, name := range slices.Sorted(maps.Keys(escaped)) {
+		for _, value := range slices.Sorted(slices.Values(escaped[name])) {
+			params = append(params, name+"="+value)
+		}
+	}
+
+	return strings.Join(params, "&")
+}
+
+// s3Escape percent-encodes every byte but the unreserved characters and, in
+// paths, the slashes, which is stricter than net/url.
+func s3Escape(s string, path bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', path && c == '/':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+
+	return b.String()
+}
+
+func sha256Sum(data []byte) []byte {
+	sum := sha256.Sum256(data)
+	return sum[:]
+}
+
+func hmacSum(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,14 @@
+package storage
+
+import (
+	"context"
+	"io"
+)
+
+// Storage keeps the uploaded files (blobs) outside of the database.
+type Storage interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	Delete(ctx context.Context, key string) error
+	// URL is the public address of the blob stored under key.
+	URL(key string) string
+}
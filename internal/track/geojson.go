@@ -0,0 +1,76 @@
+package track
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"runmate_api/internal/entity"
+)
+
+type geoJSONFeature struct {
+	Type       string             `json:"type"`
+	Geometry   *geoJSONGeometry   `json:"geometry"`
+	Properties *geoJSONProperties `json:"properties"`
+}
+
+type geoJSONGeometry struct {
+	Type        string      `json:"type"`
+	Coordinates [][]float64 `json:"coordinates"`
+}
+
+type geoJSONProperties struct {
+	ID         string       `json:"id"`
+	Title      string       `json:"title"`
+	Date       time.Time    `json:"date"`
+	Duration   int          `json:"duration"`
+	Distance   int          `json:"distance"`
+	CoordTimes []*time.Time `json:"coordTimes,omitempty"`
+}
+
+func encodeGeoJSON(w io.Writer, activity *entity.Activity) error {
+	points := newPoints(activity.Coordinates)
+	coordinates := make([][]float64, 0, len(points))
+	var times []*time.Time
+	for _, p := range points {
+		// GeoJSON positions are [longitude, latitude, altitude].
+		position := []float64{p.Long, p.Lat}
+		if p.Altitude != nil {
+			position = append(position, *p.Altitude)
+		}
+
+		coordinates = append(coordinates, position)
+		if p.Time != nil {
+			times = append(times, p.Time)
+		}
+	}
+
+	// Times are only meaningful when every position has one.
+	if len(times) != len(points) {
+		times = nil
+	}
+
+	feature := &geoJSONFeature{
+		Type: "Feature",
+		Geometry: &geoJSONGeometry{
+			Type:        "LineString",
+			Coordinates: coordinates,
+		},
+		Properties: &geoJSONProperties{
+			ID:         activity.ID.String(),
+			Title:      activity.Title,
+			Date:       activity.Date,
+			Duration:   activity.Duration,
+			Distance:   activity.Distance,
+			CoordTimes: times,
+		},
+	}
+
+	err := json.NewEncoder(w).Encode(feature)
+	if err != nil {
+		return fmt.Errorf("failed to encode geojson: %v", err)
+	}
+
+	return nil
+}
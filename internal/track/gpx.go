@@ -0,0 +1,181 @@
+package track
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"runmate_api/internal/entity"
+)
+
+const (
+	gpxNamespace                    = "http://www.topografix.com/GPX/1/1"
+	gpxTrackPointExtensionNamespace = "http://www.garmin.com/xmlschemas/TrackPointExtension/v1"
+)
+
+type gpxFile struct {
+	XMLName  xml.Name     `xml:"gpx"`
+	Xmlns    string       `xml:"xmlns,attr,omitempty"`
+	Version  string       `xml:"version,attr,omitempty"`
+	Creator  string       `xml:"creator,attr,omitempty"`
+	Metadata *gpxMetadata `xml:"metadata"`
+	Tracks   []*gpxTrack  `xml:"trk"`
+}
+
+type gpxMetadata struct {
+	Name string     `xml:"name,omitempty"`
+	Time *time.Time `xml:"time,omitempty"`
+}
+
+type gpxTrack struct {
+	Name     string        `xml:"name,omitempty"`
+	Type     string        `xml:"type,omitempty"`
+	Segments []*gpxSegment `xml:"trkseg"`
+}
+
+// gpxTrackTypes follows the values written by Garmin and Strava. GPX itself
+// leaves the track type free.
+var gpxTrackTypes = map[entity.ActivityType]string{
+	entity.ActivityTypeRun:       "running",
+	entity.ActivityTypeWalk:      "walking",
+	entity.ActivityTypeHike:      "hiking",
+	entity.ActivityTypeRide:      "cycling",
+	entity.ActivityTypeTreadmill: "treadmill_running",
+}
+
+func parseGPXTrackType(trackType string) entity.ActivityType {
+	trackType = strings.ToLower(trackType)
+	switch {
+	case strings.Contains(trackType, "treadmill"):
+		return entity.ActivityTypeTreadmill
+	case strings.Contains(trackType, "cycling"), strings.Contains(trackType, "biking"), strings.Contains(trackType, "ride"):
+		return entity.ActivityTypeRide
+	case strings.Contains(trackType, "walk"):
+		return entity.ActivityTypeWalk
+	case strings.Contains(trackType, "hik"):
+		return entity.ActivityTypeHike
+	default:
+		return entity.ActivityTypeRun
+	}
+}
+
+type gpxSegment struct {
+	Points []*gpxPoint `xml:"trkpt"`
+}
+
+type gpxPoint struct {
+	Lat        float64        `xml:"lat,attr"`
+	Long       float64        `xml:"lon,attr"`
+	Elevation  *float64       `xml:"ele,omitempty"`
+	Time       *time.Time     `xml:"time,omitempty"`
+	Extensions *gpxExtensions `xml:"extensions,omitempty"`
+}
+
+type gpxExtensions struct {
+	TrackPointExtension *gpxTrackPointExtension `xml:"TrackPointExtension"`
+}
+
+// gpxTrackPointExtension follows Garmin's TrackPointExtension, the de facto
+// standard for heart rate and cadence in GPX files.
+type gpxTrackPointExtension struct {
+	XMLName   xml.Name
+	HeartRate *int `xml:"hr,omitempty"`
+	Cadence   *int `xml:"cad,omitempty"`
+}
+
+func newGPXPoint(p *point) *gpxPoint {
+	gpxPoint := &gpxPoint{
+		Lat:       p.Lat,
+		Long:      p.Long,
+		Elevation: p.Altitude,
+		Time:      p.Time,
+	}
+
+	if p.HeartRate != nil || p.Cadence != nil {
+		gpxPoint.Extensions = &gpxExtensions{
+			TrackPointExtension: &gpxTrackPointExtension{
+				XMLName:   xml.Name{Space: gpxTrackPointExtensionNamespace, Local: "TrackPointExtension"},
+				HeartRate: p.HeartRate,
+				Cadence:   p.Cadence,
+			},
+		}
+	}
+
+	return gpxPoint
+}
+
+func (p *gpxPoint) toPoint() *point {
+	point := &point{
+		Lat:      p.Lat,
+		Long:     p.Long,
+		Time:     p.Time,
+		Altitude: p.Elevation,
+	}
+
+	if p.Extensions != nil && p.Extensions.TrackPointExtension != nil {
+		point.HeartRate = p.Extensions.TrackPointExtension.HeartRate
+		point.Cadence = p.Extensions.TrackPointExtension.Cadence
+	}
+
+	return point
+}
+
+func parseGPX(r io.Reader) (*parsedTrack, error) {
+	var file gpxFile
+	err := xml.NewDecoder(r).Decode(&file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode gpx: %v", err)
+	}
+
+	parsed := &parsedTrack{}
+	if file.Metadata != nil {
+		parsed.Title = file.Metadata.Name
+		if file.Metadata.Time != nil {
+			parsed.Date = *file.Metadata.Time
+		}
+	}
+
+	for i, track := range file.Tracks {
+		if i == 0 {
+			parsed.Type = parseGPXTrackType(track.Type)
+		}
+
+		if parsed.Title == "" {
+			parsed.Title = track.Name
+		}
+
+		for _, segment := range track.Segments {
+			for _, p := range segment.Points {
+				parsed.Points = append(parsed.Points, p.toPoint())
+			}
+		}
+	}
+
+	return parsed, nil
+}
+
+func encodeGPX(w io.Writer, activity *entity.Activity) error {
+	segment := &gpxSegment{Points: make([]*gpxPoint, 0, len(activity.Coordinates))}
+	for _, p := range newPoints(activity.Coordinates) {
+		segment.Points = append(segment.Points, newGPXPoint(p))
+	}
+
+	file := &gpxFile{
+		Xmlns:   gpxNamespace,
+		Version: "1.1",
+		Creator: creator,
+		Metadata: &gpxMetadata{
+			Name: activity.Title,
+			Time: &activity.Date,
+		},
+		Tracks: []*gpxTrack{{
+			Name:     activity.Title,
+			Type:     gpxTrackTypes[activity.Type],
+			Segments: []*gpxSegment{segment},
+		}},
+	}
+
+	return encodeXML(w, file)
+}
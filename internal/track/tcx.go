@@ -0,0 +1,161 @@
+package track
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+
+	"runmate_api/internal/entity"
+)
+
+const tcxNamespace = "http://www.garmin.com/xmlschemas/TrainingCenterDatabase/v2"
+
+type tcxFile struct {
+	XMLName    xml.Name       `xml:"TrainingCenterDatabase"`
+	Xmlns      string         `xml:"xmlns,attr,omitempty"`
+	Activities []*tcxActivity `xml:"Activities>Activity"`
+}
+
+type tcxActivity struct {
+	Sport string     `xml:"Sport,attr,omitempty"`
+	ID    *time.Time `xml:"Id"`
+	Laps  []*tcxLap  `xml:"Lap"`
+	Notes string     `xml:"Notes,omitempty"`
+}
+
+type tcxLap struct {
+	StartTime        *time.Time  `xml:"StartTime,attr,omitempty"`
+	TotalTimeSeconds float64     `xml:"TotalTimeSeconds"`
+	DistanceMeters   float64     `xml:"DistanceMeters"`
+	Intensity        string      `xml:"Intensity,omitempty"`
+	TriggerMethod    string      `xml:"TriggerMethod,omitempty"`
+	Tracks           []*tcxTrack `xml:"Track"`
+}
+
+type tcxTrack struct {
+	Points []*tcxPoint `xml:"Trackpoint"`
+}
+
+type tcxPoint struct {
+	Time      *time.Time    `xml:"Time,omitempty"`
+	Position  *tcxPosition  `xml:"Position"`
+	Altitude  *float64      `xml:"AltitudeMeters,omitempty"`
+	HeartRate *tcxHeartRate `xml:"HeartRateBpm,omitempty"`
+	Cadence   *int          `xml:"Cadence,omitempty"`
+}
+
+type tcxHeartRate struct {
+	Value int `xml:"Value"`
+}
+
+type tcxPosition struct {
+	Lat  float64 `xml:"LatitudeDegrees"`
+	Long float64 `xml:"LongitudeDegrees"`
+}
+
+func parseTCXSport(sport string) entity.ActivityType {
+	if sport == "Biking" {
+		return entity.ActivityTypeRide
+	}
+
+	return entity.ActivityTypeRun
+}
+
+// newTCXSport maps the activity type to the only sports TCX knows: Running,
+// Biking and Other.
+func newTCXSport(activityType entity.ActivityType) string {
+	switch activityType {
+	case entity.ActivityTypeRun, entity.ActivityTypeTreadmill:
+		return "Running"
+	case entity.ActivityTypeRide:
+		return "Biking"
+	default:
+		return "Other"
+	}
+}
+
+func parseTCX(r io.Reader) (*parsedTrack, error) {
+	var file tcxFile
+	err := xml.NewDecoder(r).Decode(&file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode tcx: %v", err)
+	}
+
+	parsed := &parsedTrack{}
+	for i, activity := range file.Activities {
+		if i == 0 {
+			parsed.Type = parseTCXSport(activity.Sport)
+		}
+
+		if parsed.Title == "" {
+			parsed.Title = activity.Notes
+		}
+
+		if parsed.Date.IsZero() && activity.ID != nil {
+			parsed.Date = *activity.ID
+		}
+
+		for _, lap := range activity.Laps {
+			for _, track := range lap.Tracks {
+				for _, p := range track.Points {
+					// Trackpoints without a position only carry sensor data.
+					if p.Position == nil {
+						continue
+					}
+
+					point := &point{
+						Lat:      p.Position.Lat,
+						Long:     p.Position.Long,
+						Time:     p.Time,
+						Altitude: p.Altitude,
+						Cadence:  p.Cadence,
+					}
+					if p.HeartRate != nil {
+						point.HeartRate = &p.HeartRate.Value
+					}
+
+					parsed.Points = append(parsed.Points, point)
+				}
+			}
+		}
+	}
+
+	return parsed, nil
+}
+
+func encodeTCX(w io.Writer, activity *entity.Activity) error {
+	track := &tcxTrack{Points: make([]*tcxPoint, 0, len(activity.Coordinates))}
+	for _, p := range newPoints(activity.Coordinates) {
+		tcxPoint := &tcxPoint{
+			Time:     p.Time,
+			Position: &tcxPosition{Lat: p.Lat, Long: p.Long},
+			Altitude: p.Altitude,
+			Cadence:  p.Cadence,
+		}
+		if p.HeartRate != nil {
+			tcxPoint.HeartRate = &tcxHeartRate{Value: *p.HeartRate}
+		}
+
+		track.Points = append(track.Points, tcxPoint)
+	}
+
+	file := &tcxFile{
+		Xmlns: tcxNamespace,
+		Activities: []*tcxActivity{{
+			Sport: newTCXSport(activity.Type),
+			ID:    &activity.Date,
+			Laps: []*tcxLap{{
+				StartTime:        &activity.Date,
+				TotalTimeSeconds: float64(activity.Duration),
+				DistanceMeters:   float64(activity.Distance),
+				Intensity:        "Active",
+				TriggerMethod:    "Manual",
+				Tracks:           []*tcxTrack{track},
+			}},
+			Notes: activity.Title,
+		}},
+	}
+
+	return encodeXML(w, file)
+}
@@ -0,0 +1,184 @@
+package track
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"runmate_api/internal/entity"
+	"runmate_api/internal/geo"
+)
+
+type Format string
+
+const (
+	FormatGPX     Format = "gpx"
+	FormatTCX     Format = "tcx"
+	FormatGeoJSON Format = "geojson"
+)
+
+const creator = "runmate"
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported track format")
+	ErrEmptyTrack        = errors.New("track has no points")
+)
+
+type point struct {
+	Lat       float64
+	Long      float64
+	Time      *time.Time
+	Altitude  *float64
+	HeartRate *int
+	Cadence   *int
+}
+
+type parsedTrack struct {
+	Title  string
+	Type   entity.ActivityType
+	Date   time.Time
+	Points []*point
+}
+
+func ParseFormat(format string) (Format, error) {
+	switch Format(strings.ToLower(strings.TrimPrefix(format, "."))) {
+	case FormatGPX:
+		return FormatGPX, nil
+	case FormatTCX:
+		return FormatTCX, nil
+	case FormatGeoJSON:
+		return FormatGeoJSON, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+	}
+}
+
+func (f Format) ContentType() string {
+	switch f {
+	case FormatGPX:
+		return "application/gpx+xml"
+	case FormatTCX:
+		return "application/vnd.garmin.tcx+xml"
+	case FormatGeoJSON:
+		return "application/geo+json"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+func FormatFromFilename(filename string) (Format, error) {
+	return ParseFormat(filepath.Ext(filename))
+}
+
+// Parse reads a GPX or TCX file into an activity. Distance and duration are
+// computed from the track points, never read from the file summary.
+func Parse(r io.Reader, format Format) (*entity.Activity, error) {
+	var parsed *parsedTrack
+	var err error
+	switch format {
+	case FormatGPX:
+		parsed, err = parseGPX(r)
+	case FormatTCX:
+		parsed, err = parseTCX(r)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(parsed.Points) == 0 {
+		return nil, ErrEmptyTrack
+	}
+
+	return parsed.toEntity(), nil
+}
+
+// Encode writes the activity and its ordered coordinates in the given format.
+func Encode(w io.Writer, activity *entity.Activity, format Format) error {
+	switch format {
+	case FormatGPX:
+		return encodeGPX(w, activity)
+	case FormatTCX:
+		return encodeTCX(w, activity)
+	case FormatGeoJSON:
+		return encodeGeoJSON(w, activity)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+	}
+}
+
+func encodeXML(w io.Writer, v any) error {
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	err = encoder.Encode(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode xml: %v", err)
+	}
+
+	return nil
+}
+
+func newPoints(coordinates []*entity.Coordinate) []*point {
+	points := make([]*point, 0, len(coordinates))
+	for _, coordinate := range coordinates {
+		points = append(points, &point{
+			Lat:       coordinate.Lat,
+			Long:      coordinate.Long,
+			Time:      coordinate.Time,
+			Altitude:  coordinate.Altitude,
+			HeartRate: coordinate.HeartRate,
+			Cadence:   coordinate.Cadence,
+		})
+	}
+
+	return points
+}
+
+func (t *parsedTrack) toEntity() *entity.Activity {
+	coordinates := make([]*entity.Coordinate, 0, len(t.Points))
+	for i, p := range t.Points {
+		coordinates = append(coordinates, &entity.Coordinate{
+			Lat:       p.Lat,
+			Long:      p.Long,
+			Order:     i,
+			Time:      p.Time,
+			Altitude:  p.Altitude,
+			HeartRate: p.HeartRate,
+			Cadence:   p.Cadence,
+		})
+	}
+
+	date := t.Date
+	var duration int
+	first, last := t.Points[0].Time, t.Points[len(t.Points)-1].Time
+	if first != nil {
+		if date.IsZero() {
+			date = *first
+		}
+
+		if last != nil && last.After(*first) {
+			duration = int(last.Sub(*first).Seconds())
+		}
+	}
+
+	return &entity.Activity{
+		Title:       t.Title,
+		Type:        t.Type,
+		Date:        date,
+		Duration:    duration,
+		Distance:    int(math.Round(geo.PathDistance(coordinates))),
+		Coordinates: coordinates,
+	}
+}
@@ -0,0 +1,350 @@
+package track
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"runmate_api/internal/entity"
+)
+
+const sampleGPX = `<?xml version="1.0" encoding="UTF-8"?>
+<gpx version="1.1" creator="Garmin Connect" xmlns="http://www.topografix.com/GPX/1/1"
+  xmlns:gpxtpx="http://www.garmin.com/xmlschemas/TrackPointExtension/v1">
+  <metadata>
+    <name>Pedal no parque</name>
+    <time>2024-05-01T07:00:00Z</time>
+  </metadata>
+  <trk>
+    <name>Ignored track name</name>
+    <type>cycling</type>
+    <trkseg>
+      <trkpt lat="-23.58700" lon="-46.65800">
+        <ele>760.5</ele>
+        <time>2024-05-01T07:00:05Z</time>
+        <extensions>
+          <gpxtpx:TrackPointExtension>
+            <gpxtpx:hr>120</gpxtpx:hr>
+            <gpxtpx:cad>80</gpxtpx:cad>
+          </gpxtpx:TrackPointExtension>
+        </extensions>
+      </trkpt>
+      <trkpt lat="-23.58800" lon="-46.65800">
+        <ele>762</ele>
+        <time>2024-05-01T07:00:35Z</time>
+      </trkpt>
+    </trkseg>
+    <trkseg>
+      <trkpt lat="-23.58900" lon="-46.65800">
+        <time>2024-05-01T07:01:05Z</time>
+      </trkpt>
+    </trkseg>
+  </trk>
+</gpx>`
+
+const sampleTCX = `<?xml version="1.0" encoding="UTF-8"?>
+<TrainingCenterDatabase xmlns="http://www.garmin.com/xmlschemas/TrainingCenterDatabase/v2">
+  <Activities>
+    <Activity Sport="Running">
+      <Id>2024-05-01T07:00:00Z</Id>
+      <Lap StartTime="2024-05-01T07:00:00Z">
+        <TotalTimeSeconds>9999</TotalTimeSeconds>
+        <DistanceMeters>99999</DistanceMeters>
+        <Track>
+          <Trackpoint>
+            <Time>2024-05-01T07:00:00Z</Time>
+            <Position>
+              <LatitudeDegrees>-23.58700</LatitudeDegrees>
+              <LongitudeDegrees>-46.65800</LongitudeDegrees>
+            </Position>
+            <AltitudeMeters>760</AltitudeMeters>
+            <HeartRateBpm><Value>140</Value></HeartRateBpm>
+            <Cadence>85</Cadence>
+          </Trackpoint>
+          <Trackpoint>
+            <Time>2024-05-01T07:00:10Z</Time>
+            <HeartRateBpm><Value>142</Value></HeartRateBpm>
+          </Trackpoint>
+          <Trackpoint>
+            <Time>2024-05-01T07:01:00Z</Time>
+            <Position>
+              <LatitudeDegrees>-23.58900</LatitudeDegrees>
+              <LongitudeDegrees>-46.65800</LongitudeDegrees>
+            </Position>
+          </Trackpoint>
+        </Track>
+      </Lap>
+      <Notes>Treino leve</Notes>
+    </Activity>
+  </Activities>
+</TrainingCenterDatabase>`
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func at(seconds int) *time.Time {
+	return ptr(time.Date(2024, 5, 1, 7, 0, seconds, 0, time.UTC))
+}
+
+func TestParseGPX(t *testing.T) {
+	activity, err := Parse(strings.NewReader(sampleGPX), FormatGPX)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if activity.Title != "Pedal no parque" {
+		t.Errorf("title = %q, want the metadata name", activity.Title)
+	}
+
+	if activity.Type != entity.ActivityTypeRide {
+		t.Errorf("type = %v, want ride", activity.Type)
+	}
+
+	if !activity.Date.Equal(*at(0)) {
+		t.Errorf("date = %v, want the metadata time", activity.Date)
+	}
+
+	if activity.Duration != 60 {
+		t.Errorf("duration = %d, want 60", activity.Duration)
+	}
+
+	// Two tenths of a thousandth of a degree of latitude.
+	if activity.Distance != 222 {
+		t.Errorf("distance = %d, want 222", activity.Distance)
+	}
+
+	if len(activity.Coordinates) != 3 {
+		t.Fatalf("got %d coordinates across the segments, want 3", len(activity.Coordinates))
+	}
+
+	first := activity.Coordinates[0]
+	if first.Altitude == nil || *first.Altitude != 760.5 {
+		t.Errorf("altitude = %v, want 760.5", first.Altitude)
+	}
+
+	if first.HeartRate == nil || *first.HeartRate != 120 || first.Cadence == nil || *first.Cadence != 80 {
+		t.Errorf("heart rate and cadence = %v %v, want 120 80", first.HeartRate, first.Cadence)
+	}
+
+	for i, coordinate := range activity.Coordinates {
+		if coordinate.Order != i {
+			t.Errorf("coordinate %d has order %d", i, coordinate.Order)
+		}
+	}
+}
+
+func TestParseTCX(t *testing.T) {
+	activity, err := Parse(strings.NewReader(sampleTCX), FormatTCX)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if activity.Title != "Treino leve" || activity.Type != entity.ActivityTypeRun {
+		t.Errorf("title and type = %q %v, want the notes and run", activity.Title, activity.Type)
+	}
+
+	// The lap summary is ignored in favor of the track points.
+	if activity.Duration != 60 || activity.Distance != 222 {
+		t.Errorf("duration and distance = %d %d, want 60 222", activity.Duration, activity.Distance)
+	}
+
+	if len(activity.Coordinates) != 2 {
+		t.Fatalf("got %d coordinates, want 2 without the one lacking a position", len(activity.Coordinates))
+	}
+
+	first := activity.Coordinates[0]
+	if first.HeartRate == nil || *first.HeartRate != 140 || first.Cadence == nil || *first.Cadence != 85 {
+		t.Errorf("heart rate and cadence = %v %v, want 140 85", first.HeartRate, first.Cadence)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		format Format
+		want   error
+	}{
+		{name: "gpx without points", data: `<gpx><trk><trkseg></trkseg></trk></gpx>`, format: FormatGPX, want: ErrEmptyTrack},
+		{name: "tcx without positions", data: `<TrainingCenterDatabase><Activities><Activity><Lap><Track><Trackpoint></Trackpoint></Track></Lap></Activity></Activities></TrainingCenterDatabase>`, format: FormatTCX, want: ErrEmptyTrack},
+		{name: "geojson is export only", data: `{}`, format: FormatGeoJSON, want: ErrUnsupportedFormat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tt.data), tt.format)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Parse() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	_, err := Parse(strings.NewReader("not xml"), FormatGPX)
+	if err == nil {
+		t.Error("Parse() of malformed gpx succeeded")
+	}
+}
+
+func sampleActivity(activityType entity.ActivityType) *entity.Activity {
+	return &entity.Activity{
+		ID:       uuid.MustParse("8f2a8f9e-3c7d-4a7b-9b43-0f5d3d6a1c20"),
+		Title:    "Longão de domingo",
+		Type:     activityType,
+		Date:     *at(0),
+		Duration: 60,
+		Distance: 222,
+		Coordinates: []*entity.Coordinate{
+			{Lat: -23.587, Long: -46.658, Time: at(0), Altitude: ptr(760.5), HeartRate: ptr(140), Cadence: ptr(85)},
+			{Lat: -23.588, Long: -46.658, Time: at(30), Altitude: ptr(761.0)},
+			{Lat: -23.589, Long: -46.658, Time: at(60), HeartRate: ptr(150)},
+		},
+	}
+}
+
+func equalPointers[T comparable](a, b *T) bool {
+	return a == nil && b == nil || a != nil && b != nil && *a == *b
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   Format
+		typ      entity.ActivityType
+		wantType entity.ActivityType
+	}{
+		{name: "gpx run", format: FormatGPX, typ: entity.ActivityTypeRun, wantType: entity.ActivityTypeRun},
+		{name: "gpx hike", format: FormatGPX, typ: entity.ActivityTypeHike, wantType: entity.ActivityTypeHike},
+		{name: "gpx treadmill", format: FormatGPX, typ: entity.ActivityTypeTreadmill, wantType: entity.ActivityTypeTreadmill},
+		{name: "tcx ride", format: FormatTCX, typ: entity.ActivityTypeRide, wantType: entity.ActivityTypeRide},
+		// TCX only knows running and biking.
+		{name: "tcx walk", format: FormatTCX, typ: entity.ActivityTypeWalk, wantType: entity.ActivityTypeRun},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := sampleActivity(tt.typ)
+			var b bytes.Buffer
+			err := Encode(&b, want, tt.format)
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+
+			got, err := Parse(&b, tt.format)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			if got.Title != want.Title || got.Type != tt.wantType || !got.Date.Equal(want.Date) {
+				t.Errorf("got %q %v %v, want %q %v %v", got.Title, got.Type, got.Date, want.Title, tt.wantType, want.Date)
+			}
+
+			if got.Duration != want.Duration || got.Distance != want.Distance {
+				t.Errorf("duration and distance = %d %d, want %d %d", got.Duration, got.Distance, want.Duration, want.Distance)
+			}
+
+			if len(got.Coordinates) != len(want.Coordinates) {
+				t.Fatalf("got %d coordinates, want %d", len(got.Coordinates), len(want.Coordinates))
+			}
+
+			for i, c := range got.Coordinates {
+				w := want.Coordinates[i]
+				if c.Lat != w.Lat || c.Long != w.Long || !c.Time.Equal(*w.Time) {
+					t.Errorf("coordinate %d = %v %v %v, want %v %v %v", i, c.Lat, c.Long, c.Time, w.Lat, w.Long, w.Time)
+				}
+
+				if !equalPointers(c.Altitude, w.Altitude) || !equalPointers(c.HeartRate, w.HeartRate) || !equalPointers(c.Cadence, w.Cadence) {
+					t.Errorf("coordinate %d sensors = %v %v %v, want %v %v %v", i, c.Altitude, c.HeartRate, c.Cadence, w.Altitude, w.HeartRate, w.Cadence)
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeGeoJSON(t *testing.T) {
+	tests := []struct {
+		name      string
+		untimed   bool
+		wantTimes int
+	}{
+		{name: "timed", wantTimes: 3},
+		{name: "partly timed drops the times", untimed: true, wantTimes: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			activity := sampleActivity(entity.ActivityTypeRun)
+			if tt.untimed {
+				activity.Coordinates[1].Time = nil
+			}
+
+			var b bytes.Buffer
+			err := Encode(&b, activity, FormatGeoJSON)
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+
+			var feature geoJSONFeature
+			err = json.Unmarshal(b.Bytes(), &feature)
+			if err != nil {
+				t.Fatalf("invalid geojson: %v", err)
+			}
+
+			if feature.Type != "Feature" || feature.Geometry.Type != "LineString" {
+				t.Errorf("got a %s of %s, want a Feature of LineString", feature.Type, feature.Geometry.Type)
+			}
+
+			positions := feature.Geometry.Coordinates
+			if len(positions) != 3 {
+				t.Fatalf("got %d positions, want 3", len(positions))
+			}
+
+			// Positions are longitude first, with the altitude when known.
+			if len(positions[0]) != 3 || positions[0][0] != -46.658 || positions[0][1] != -23.587 || positions[0][2] != 760.5 {
+				t.Errorf("first position = %v, want [-46.658 -23.587 760.5]", positions[0])
+			}
+
+			if len(positions[2]) != 2 {
+				t.Errorf("last position = %v, want no altitude", positions[2])
+			}
+
+			if len(feature.Properties.CoordTimes) != tt.wantTimes {
+				t.Errorf("got %d coordTimes, want %d", len(feature.Properties.CoordTimes), tt.wantTimes)
+			}
+
+			if feature.Properties.ID != activity.ID.String() || feature.Properties.Title != activity.Title {
+				t.Errorf("properties = %+v, want the activity", feature.Properties)
+			}
+		})
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Format
+		err  error
+	}{
+		{in: "gpx", want: FormatGPX},
+		{in: ".TCX", want: FormatTCX},
+		{in: "geojson", want: FormatGeoJSON},
+		{in: "kml", err: ErrUnsupportedFormat},
+		{in: "", err: ErrUnsupportedFormat},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseFormat(tt.in)
+		if got != tt.want || !errors.Is(err, tt.err) {
+			t.Errorf("ParseFormat(%q) = %q, %v, want %q, %v", tt.in, got, err, tt.want, tt.err)
+		}
+	}
+
+	if got, _ := FormatFromFilename("corrida.gpx"); got != FormatGPX {
+		t.Errorf("FormatFromFilename() = %q, want gpx", got)
+	}
+}